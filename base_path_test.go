@@ -0,0 +1,68 @@
+package gocloak
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pathServer(paths chan<- string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths <- r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+}
+
+func TestGocloak_RelativePath(t *testing.T) {
+	t.Parallel()
+	paths := make(chan string, 1)
+	server := pathServer(paths)
+	defer server.Close()
+	ctx := context.Background()
+
+	client := NewClient(server.URL + "/")
+	_, err := client.GetIssuer(ctx, "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, "/auth/realms/realm", <-paths)
+
+	for _, relativePath := range []string{"", "/"} {
+		client = NewClient(server.URL, SetAuthRelativePath(relativePath))
+		_, err = client.GetIssuer(ctx, "realm")
+		assert.NoError(t, err)
+		assert.Equal(t, "/realms/realm", <-paths)
+		_, err = client.GetRealm(ctx, "token", "realm")
+		assert.NoError(t, err)
+		assert.Equal(t, "/admin/realms/realm", <-paths)
+		_, err = client.GetServerInfo(ctx, "token")
+		assert.NoError(t, err)
+		assert.Equal(t, "/admin/serverinfo", <-paths)
+	}
+
+	client = NewClient(server.URL, SetAuthRelativePath("/sso/"))
+	_, err = client.GetIssuer(ctx, "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, "/sso/realms/realm", <-paths)
+}
+
+func TestGocloak_AdminBasePath(t *testing.T) {
+	t.Parallel()
+	publicPaths := make(chan string, 1)
+	public := pathServer(publicPaths)
+	defer public.Close()
+	adminPaths := make(chan string, 1)
+	admin := pathServer(adminPaths)
+	defer admin.Close()
+	ctx := context.Background()
+
+	client := NewClient(public.URL, SetAdminBasePath(admin.URL+"/"), SetAuthRelativePath(""))
+	_, err := client.GetIssuer(ctx, "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, "/realms/realm", <-publicPaths)
+	_, err = client.GetRealm(ctx, "token", "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, "/admin/realms/realm", <-adminPaths)
+}
@@ -0,0 +1,271 @@
+package gocloak
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// certsPrefetchRatio is the part of the cache lifetime after which the certs are prefetched
+	certsPrefetchRatio = 0.9
+	// certsFetchTimeout limits a request for the certs, which is shared by all callers
+	certsFetchTimeout = 30 * time.Second
+)
+
+// CertsCacheStats holds the counters of the certs cache
+type CertsCacheStats struct {
+	// Hits is the number of lookups served from the cache
+	Hits uint64
+	// Misses is the number of lookups which needed a request to the certs endpoint
+	Misses uint64
+	// Refreshes is the number of successful requests to the certs endpoint
+	Refreshes uint64
+	// Errors is the number of failed requests to the certs endpoint
+	Errors uint64
+	// KeyMisses is the number of tokens signed with a key unknown to the cache
+	KeyMisses uint64
+	// RateLimited is the number of key misses which did not refresh the certs because of the refresh interval
+	RateLimited uint64
+	// Fallbacks is the number of failed requests answered with the last known or the stored certs
+	Fallbacks uint64
+	// Realms is the number of cached realms
+	Realms int
+}
+
+type certsFetcher func(ctx context.Context, realm string) (*CertResponse, http.Header, error)
+
+type certsEntry struct {
+	certs     *CertResponse
+	fetchedAt time.Time
+	expiresAt time.Time
+	prefetch  *time.Timer
+}
+
+type certsCall struct {
+	done  chan struct{}
+	certs *CertResponse
+	err   error
+}
+
+// certsCache is a concurrency-safe cache of the public keys of the realms.
+// Concurrent requests of the certs of a realm share a single request to keycloak
+type certsCache struct {
+	fetch           certsFetcher
+	ttl             time.Duration
+	refreshInterval time.Duration
+	prefetch        bool
+	store           CertsStore
+	now             func() time.Time
+	fetchTimeout    time.Duration
+	ctx             context.Context
+	cancel          context.CancelFunc
+
+	mu      sync.Mutex
+	entries map[string]*certsEntry
+	calls   map[string]*certsCall
+	stats   CertsCacheStats
+	closed  bool
+}
+
+func newCertsCache(fetch certsFetcher, ttl, refreshInterval time.Duration, prefetch bool, store CertsStore) *certsCache {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &certsCache{
+		fetch:           fetch,
+		ttl:             ttl,
+		refreshInterval: refreshInterval,
+		prefetch:        prefetch,
+		store:           store,
+		now:             time.Now,
+		fetchTimeout:    certsFetchTimeout,
+		ctx:             ctx,
+		cancel:          cancel,
+		entries:         make(map[string]*certsEntry),
+		calls:           make(map[string]*certsCall),
+	}
+}
+
+// get returns the cached certs of the realm or fetches them if they are missing or expired
+func (c *certsCache) get(ctx context.Context, realm string) (*CertResponse, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[realm]; ok && c.now().Before(entry.expiresAt) {
+		c.stats.Hits++
+		c.mu.Unlock()
+		return entry.certs, nil
+	}
+	c.stats.Misses++
+	call := c.start(realm)
+	c.mu.Unlock()
+
+	return call.wait(ctx)
+}
+
+// refresh fetches the certs of the realm because a token is signed with an unknown key.
+// The certs are fetched at most once per refresh interval, otherwise the cached certs are returned
+func (c *certsCache) refresh(ctx context.Context, realm string) (*CertResponse, error) {
+	c.mu.Lock()
+	c.stats.KeyMisses++
+	if entry, ok := c.entries[realm]; ok && c.now().Sub(entry.fetchedAt) < c.refreshInterval {
+		c.stats.RateLimited++
+		c.mu.Unlock()
+		return entry.certs, nil
+	}
+	call := c.start(realm)
+	c.mu.Unlock()
+
+	return call.wait(ctx)
+}
+
+// seed caches the given certs as if they were just fetched, without a request to keycloak.
+// A token signed with an unknown key refreshes seeded certs right away
+func (c *certsCache) seed(realm string, certs *CertResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(realm, certs, nil)
+	c.entries[realm].fetchedAt = time.Time{}
+}
+
+// close stops the prefetch timers and cancels the requests in flight.
+// The cached certs are still served, but no new certs are fetched
+func (c *certsCache) close() {
+	c.cancel()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for _, entry := range c.entries {
+		if entry.prefetch != nil {
+			entry.prefetch.Stop()
+			entry.prefetch = nil
+		}
+	}
+}
+
+// getStats returns a snapshot of the cache counters
+func (c *certsCache) getStats() CertsCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Realms = len(c.entries)
+	return stats
+}
+
+// start starts fetching the certs of the realm or returns the request in flight, c.mu must be held
+func (c *certsCache) start(realm string) *certsCall {
+	if call, ok := c.calls[realm]; ok {
+		return call
+	}
+	call := &certsCall{done: make(chan struct{})}
+	c.calls[realm] = call
+
+	_, cached := c.entries[realm]
+
+	go func() {
+		// the request is shared by all callers, so it is not bound to the context of one of them
+		ctx, cancel := context.WithTimeout(c.ctx, c.fetchTimeout)
+		certs, header, err := c.fetch(ctx, realm)
+		cancel()
+
+		var stored *CertResponse
+		if c.store != nil {
+			if err == nil {
+				_ = c.store.SaveCerts(c.ctx, realm, certs)
+			} else if !cached {
+				stored, _ = c.store.LoadCerts(c.ctx, realm)
+			}
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.calls, realm)
+		call.certs, call.err = certs, err
+		if err != nil {
+			c.stats.Errors++
+			if fallback := c.fallback(realm, stored); fallback != nil {
+				c.stats.Fallbacks++
+				call.certs, call.err = fallback, nil
+			}
+		} else {
+			c.stats.Refreshes++
+			c.set(realm, certs, header)
+		}
+		close(call.done)
+	}()
+
+	return call
+}
+
+// set caches the certs for as long as the cache headers allow, c.mu must be held
+func (c *certsCache) set(realm string, certs *CertResponse, header http.Header) {
+	now := c.now()
+	ttl := cacheTTL(header, now, c.ttl)
+	entry := &certsEntry{
+		certs:     certs,
+		fetchedAt: now,
+		expiresAt: now.Add(ttl),
+	}
+	if old, ok := c.entries[realm]; ok && old.prefetch != nil {
+		old.prefetch.Stop()
+	}
+	if c.prefetch && ttl > 0 && !c.closed {
+		entry.prefetch = time.AfterFunc(time.Duration(float64(ttl)*certsPrefetchRatio), func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if !c.closed {
+				c.start(realm)
+			}
+		})
+	}
+	c.entries[realm] = entry
+}
+
+// fallback returns the last known certs of the realm, or the stored ones, after a failed request.
+// They are kept for the refresh interval before keycloak is asked again, c.mu must be held
+func (c *certsCache) fallback(realm string, stored *CertResponse) *CertResponse {
+	certs := stored
+	if entry, ok := c.entries[realm]; ok {
+		certs = entry.certs
+	}
+	if certs == nil {
+		return nil
+	}
+	now := c.now()
+	if entry, ok := c.entries[realm]; ok && entry.prefetch != nil {
+		entry.prefetch.Stop()
+	}
+	c.entries[realm] = &certsEntry{
+		certs:     certs,
+		fetchedAt: now,
+		expiresAt: now.Add(c.refreshInterval),
+	}
+	return certs
+}
+
+func (call *certsCall) wait(ctx context.Context) (*CertResponse, error) {
+	select {
+	case <-call.done:
+		return call.certs, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// cacheTTL returns the lifetime given by the max-age directive or the Expires header.
+// Keycloak serves the certs with "no-cache", in that case the default lifetime is used,
+// because the keys are needed to verify every token
+func cacheTTL(header http.Header, now time.Time, defaultTTL time.Duration) time.Duration {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(directive)
+		if strings.HasPrefix(directive, "max-age=") {
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && seconds > 0 {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil && expires.After(now) {
+		return expires.Sub(now)
+	}
+	return defaultTTL
+}
@@ -0,0 +1,279 @@
+package gocloak
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertsCache_DeduplicatesConcurrentRequests(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	cache := newCertsCache(func(ctx context.Context, realm string) (*CertResponse, http.Header, error) {
+		atomic.AddInt32(&fetches, 1)
+		time.Sleep(50 * time.Millisecond)
+		return &CertResponse{}, http.Header{}, nil
+	}, time.Minute, time.Second, false, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.get(context.Background(), "realm")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	_, err := cache.get(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fetches))
+
+	stats := cache.getStats()
+	assert.EqualValues(t, 1, stats.Refreshes)
+	assert.EqualValues(t, 11, stats.Hits+stats.Misses)
+	assert.True(t, stats.Hits >= 1)
+	assert.Equal(t, 1, stats.Realms)
+}
+
+func TestCertsCache_Expiry(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	header := http.Header{}
+	header.Set("Cache-Control", "public, max-age=30")
+	cache := newCertsCache(func(ctx context.Context, realm string) (*CertResponse, http.Header, error) {
+		atomic.AddInt32(&fetches, 1)
+		return &CertResponse{}, header, nil
+	}, time.Hour, time.Second, false, nil)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	_, err := cache.get(context.Background(), "realm")
+	assert.NoError(t, err)
+	now = now.Add(20 * time.Second)
+	_, err = cache.get(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fetches))
+
+	now = now.Add(20 * time.Second)
+	_, err = cache.get(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&fetches), "max-age has not been honoured")
+}
+
+func TestCertsCache_RefreshIsRateLimited(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	cache := newCertsCache(func(ctx context.Context, realm string) (*CertResponse, http.Header, error) {
+		atomic.AddInt32(&fetches, 1)
+		return &CertResponse{}, http.Header{}, nil
+	}, time.Hour, 10*time.Second, false, nil)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	_, err := cache.get(context.Background(), "realm")
+	assert.NoError(t, err)
+	_, err = cache.refresh(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fetches))
+
+	now = now.Add(11 * time.Second)
+	_, err = cache.refresh(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&fetches))
+
+	stats := cache.getStats()
+	assert.EqualValues(t, 2, stats.KeyMisses)
+	assert.EqualValues(t, 1, stats.RateLimited)
+}
+
+func TestCertsCache_ErrorsAreNotCached(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	cache := newCertsCache(func(ctx context.Context, realm string) (*CertResponse, http.Header, error) {
+		if atomic.AddInt32(&fetches, 1) == 1 {
+			return nil, nil, errors.New("503 Service Unavailable")
+		}
+		return &CertResponse{}, http.Header{}, nil
+	}, time.Hour, time.Second, false, nil)
+
+	_, err := cache.get(context.Background(), "realm")
+	assert.Error(t, err)
+	_, err = cache.get(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cache.getStats().Errors)
+}
+
+func TestCertsCache_WaitHonoursContext(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	defer close(release)
+	cache := newCertsCache(func(ctx context.Context, realm string) (*CertResponse, http.Header, error) {
+		<-release
+		return &CertResponse{}, http.Header{}, nil
+	}, time.Hour, time.Second, false, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := cache.get(ctx, "realm")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestCertsCache_Prefetch(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	cache := newCertsCache(func(ctx context.Context, realm string) (*CertResponse, http.Header, error) {
+		atomic.AddInt32(&fetches, 1)
+		return &CertResponse{}, http.Header{}, nil
+	}, 100*time.Millisecond, time.Second, true, nil)
+
+	_, err := cache.get(context.Background(), "realm")
+	assert.NoError(t, err)
+	time.Sleep(250 * time.Millisecond)
+	assert.True(t, atomic.LoadInt32(&fetches) > 1, "certs have not been prefetched")
+}
+
+func TestCertsCache_CloseStopsPrefetch(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	cache := newCertsCache(func(ctx context.Context, realm string) (*CertResponse, http.Header, error) {
+		atomic.AddInt32(&fetches, 1)
+		return &CertResponse{}, http.Header{}, nil
+	}, 100*time.Millisecond, time.Second, true, nil)
+
+	_, err := cache.get(context.Background(), "realm")
+	assert.NoError(t, err)
+	cache.close()
+	time.Sleep(250 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
+
+func TestCertsCache_FetchTimeout(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	cache := newCertsCache(func(ctx context.Context, realm string) (*CertResponse, http.Header, error) {
+		if atomic.AddInt32(&fetches, 1) == 1 {
+			<-ctx.Done()
+			return nil, nil, ctx.Err()
+		}
+		return &CertResponse{}, http.Header{}, nil
+	}, time.Hour, time.Second, false, nil)
+	cache.fetchTimeout = 50 * time.Millisecond
+
+	_, err := cache.get(context.Background(), "realm")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	_, err = cache.get(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&fetches))
+}
+
+func TestGocloak_CloseCancelsHangingCertsRequest(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	errs := make(chan error, 1)
+	go func() {
+		_, err := client.GetCerts(context.Background(), "realm")
+		errs <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	client.Close()
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the request of the certs has not been cancelled")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	defaultTTL := 10 * time.Minute
+
+	header := http.Header{}
+	assert.Equal(t, defaultTTL, cacheTTL(header, now, defaultTTL))
+	header.Set("Cache-Control", "no-cache")
+	assert.Equal(t, defaultTTL, cacheTTL(header, now, defaultTTL))
+	header.Set("Cache-Control", "public, max-age=300")
+	assert.Equal(t, 5*time.Minute, cacheTTL(header, now, defaultTTL))
+
+	header = http.Header{}
+	header.Set("Expires", now.Add(time.Hour).UTC().Format(http.TimeFormat))
+	ttl := cacheTTL(header, now, defaultTTL)
+	assert.True(t, ttl > 59*time.Minute && ttl <= time.Hour, "unexpected ttl %s", ttl)
+}
+
+func TestGocloak_DecodeAccessTokenWithRotatedKey(t *testing.T) {
+	t.Parallel()
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	var rotated int32
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		certs := CertResponse{Keys: []*CertResponseKey{rsaCertKey("old", &oldKey.PublicKey)}}
+		if atomic.LoadInt32(&rotated) == 1 {
+			certs.Keys = []*CertResponseKey{rsaCertKey("new", &newKey.PublicKey)}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(certs)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, SetCertCacheRefreshInterval(0))
+	claims := jwt.MapClaims{"exp": float64(time.Now().Add(time.Minute).Unix())}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "old"
+	signed, err := token.SignedString(oldKey)
+	assert.NoError(t, err)
+	_, _, err = client.DecodeAccessToken(context.Background(), signed, "realm")
+	assert.NoError(t, err)
+
+	atomic.StoreInt32(&rotated, 1)
+	token = jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "new"
+	signed, err = token.SignedString(newKey)
+	assert.NoError(t, err)
+	_, _, err = client.DecodeAccessToken(context.Background(), signed, "realm")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&fetches))
+
+	stats := client.GetCertsCacheStats()
+	assert.EqualValues(t, 1, stats.KeyMisses)
+	assert.EqualValues(t, 2, stats.Refreshes)
+}
+
+func rsaCertKey(kid string, key *rsa.PublicKey) *CertResponseKey {
+	return &CertResponseKey{
+		Kid: StringP(kid),
+		Kty: StringP("RSA"),
+		Alg: StringP("RS256"),
+		Use: StringP("sig"),
+		N:   StringP(base64.RawURLEncoding.EncodeToString(key.N.Bytes())),
+		E:   StringP(base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())),
+	}
+}
@@ -0,0 +1,132 @@
+package gocloak
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CertsStore persists the last fetched certs of the realms,
+// so tokens can still be verified while the certs endpoint is unreachable
+type CertsStore interface {
+	// LoadCerts returns the stored certs of the realm or nil if there are none
+	LoadCerts(ctx context.Context, realm string) (*CertResponse, error)
+	// SaveCerts stores the certs of the realm
+	SaveCerts(ctx context.Context, realm string, certs *CertResponse) error
+}
+
+type fileCertsStore struct {
+	dir string
+}
+
+// NewFileCertsStore creates a CertsStore which keeps the certs of every realm as a JWKS document in the given directory
+func NewFileCertsStore(dir string) CertsStore {
+	return &fileCertsStore{dir: dir}
+}
+
+func (store *fileCertsStore) path(realm string) string {
+	return filepath.Join(store.dir, filepath.Base(realm)+".json")
+}
+
+func (store *fileCertsStore) LoadCerts(ctx context.Context, realm string) (*CertResponse, error) {
+	data, err := ioutil.ReadFile(store.path(realm))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var certs CertResponse
+	if err := json.Unmarshal(data, &certs); err != nil {
+		return nil, err
+	}
+	return &certs, nil
+}
+
+func (store *fileCertsStore) SaveCerts(ctx context.Context, realm string, certs *CertResponse) error {
+	data, err := json.Marshal(certs)
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first, so a concurrent load never reads a partial document
+	tmp, err := ioutil.TempFile(store.dir, filepath.Base(realm)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), store.path(realm))
+}
+
+// parsePublicKey converts a PEM encoded or a base64 encoded DER public key,
+// like the public_key of the IssuerResponse, into a key of a JWKS document
+func parsePublicKey(publicKey string) (*CertResponseKey, error) {
+	publicKey = strings.TrimSpace(publicKey)
+
+	var der []byte
+	if block, _ := pem.Decode([]byte(publicKey)); block != nil {
+		der = block.Bytes
+	} else {
+		var err error
+		der, err = base64.StdEncoding.DecodeString(publicKey)
+		if err != nil {
+			return nil, errors.New("the public key is neither PEM nor base64 encoded")
+		}
+	}
+
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		rsaKey, rsaErr := x509.ParsePKCS1PublicKey(der)
+		if rsaErr != nil {
+			return nil, err
+		}
+		key = rsaKey
+	}
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return &CertResponseKey{
+			Kty: StringP("RSA"),
+			Use: StringP("sig"),
+			N:   StringP(base64.RawURLEncoding.EncodeToString(key.N.Bytes())),
+			E:   StringP(base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())),
+		}, nil
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return &CertResponseKey{
+			Kty: StringP("EC"),
+			Use: StringP("sig"),
+			Crv: StringP(key.Curve.Params().Name),
+			X:   StringP(base64.RawURLEncoding.EncodeToString(padBytes(key.X.Bytes(), size))),
+			Y:   StringP(base64.RawURLEncoding.EncodeToString(padBytes(key.Y.Bytes(), size))),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported public key type: %T", key)
+}
+
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
@@ -0,0 +1,184 @@
+package gocloak
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileCertsStore(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "gocloak-certs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	store := NewFileCertsStore(dir)
+
+	certs, err := store.LoadCerts(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.Nil(t, certs)
+
+	expected := &CertResponse{Keys: []*CertResponseKey{{Kid: StringP("kid"), Kty: StringP("RSA")}}}
+	assert.NoError(t, store.SaveCerts(context.Background(), "realm", expected))
+	certs, err = store.LoadCerts(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, certs)
+}
+
+func TestParsePublicKey(t *testing.T) {
+	t.Parallel()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	assert.NoError(t, err)
+
+	// keycloak returns the public key base64 encoded without the PEM armor
+	key, err := parsePublicKey(base64.StdEncoding.EncodeToString(der))
+	assert.NoError(t, err)
+	assert.Equal(t, "RSA", PString(key.Kty))
+	assert.Equal(t, rsaCertKey("", &rsaKey.PublicKey).N, key.N)
+
+	key, err = parsePublicKey(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	assert.NoError(t, err)
+	assert.Equal(t, "RSA", PString(key.Kty))
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	der, err = x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	assert.NoError(t, err)
+	key, err = parsePublicKey(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	assert.NoError(t, err)
+	assert.Equal(t, "EC", PString(key.Kty))
+	assert.Equal(t, "P-384", PString(key.Crv))
+
+	_, err = parsePublicKey("not a key")
+	assert.Error(t, err)
+}
+
+func TestGocloak_DecodeAccessTokenWithRealmPublicKey(t *testing.T) {
+	t.Parallel()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+
+	// the client points to a closed port, so any request to the certs endpoint fails
+	client := NewClient("http://127.0.0.1:1")
+	assert.NoError(t, client.SetRealmPublicKey("realm", base64.StdEncoding.EncodeToString(der)))
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"exp": float64(time.Now().Add(time.Minute).Unix())})
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+
+	_, _, err = client.DecodeAccessToken(context.Background(), signed, "realm")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, client.GetCertsCacheStats().Misses)
+
+	// a token with a key id is not verified with the public key, but the certs are refreshed
+	token.Header["kid"] = "kid"
+	signed, err = token.SignedString(key)
+	assert.NoError(t, err)
+	_, _, err = client.DecodeAccessToken(context.Background(), signed, "realm")
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, client.GetCertsCacheStats().KeyMisses)
+}
+
+func TestGocloak_SetCertsDefaultRealm(t *testing.T) {
+	t.Parallel()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+
+	client := NewClient("http://127.0.0.1:1", SetDefaultRealm("realm"))
+	assert.NoError(t, client.SetRealmPublicKey("", base64.StdEncoding.EncodeToString(der)))
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"exp": float64(time.Now().Add(time.Minute).Unix())})
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+	_, _, err = client.DecodeAccessToken(context.Background(), signed, "realm")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, client.GetCertsCacheStats().Misses)
+}
+
+type memoryCertsStore struct {
+	certs map[string]*CertResponse
+}
+
+func (store *memoryCertsStore) LoadCerts(ctx context.Context, realm string) (*CertResponse, error) {
+	return store.certs[realm], nil
+}
+
+func (store *memoryCertsStore) SaveCerts(ctx context.Context, realm string, certs *CertResponse) error {
+	store.certs[realm] = certs
+	return nil
+}
+
+func TestCertsCache_FallsBackToStore(t *testing.T) {
+	t.Parallel()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	store := &memoryCertsStore{certs: map[string]*CertResponse{
+		"realm": {Keys: []*CertResponseKey{rsaCertKey("kid", &key.PublicKey)}},
+	}}
+	client := NewClient(server.URL, SetCertsStore(store))
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"exp": float64(time.Now().Add(time.Minute).Unix())})
+	token.Header["kid"] = "kid"
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+
+	_, _, err = client.DecodeAccessToken(context.Background(), signed, "realm")
+	assert.NoError(t, err)
+	stats := client.GetCertsCacheStats()
+	assert.EqualValues(t, 1, stats.Errors)
+	assert.EqualValues(t, 1, stats.Fallbacks)
+}
+
+func TestCertsCache_FallsBackToLastKnownCerts(t *testing.T) {
+	t.Parallel()
+	fail := false
+	cache := newCertsCache(func(ctx context.Context, realm string) (*CertResponse, http.Header, error) {
+		if fail {
+			return nil, nil, errors.New("503 Service Unavailable")
+		}
+		return &CertResponse{Keys: []*CertResponseKey{{Kid: StringP("kid")}}}, http.Header{}, nil
+	}, time.Minute, time.Second, false, nil)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	_, err := cache.get(context.Background(), "realm")
+	assert.NoError(t, err)
+
+	fail = true
+	now = now.Add(2 * time.Minute)
+	certs, err := cache.get(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, "kid", PString(certs.Keys[0].Kid))
+
+	// the stale certs are kept for the refresh interval before keycloak is asked again
+	_, err = cache.get(context.Background(), "realm")
+	assert.NoError(t, err)
+	stats := cache.getStats()
+	assert.EqualValues(t, 1, stats.Errors)
+	assert.EqualValues(t, 1, stats.Fallbacks)
+}
@@ -1,10 +1,15 @@
 package gocloak
 
 import (
-	"encoding/base64"
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -13,11 +18,43 @@ import (
 )
 
 type gocloak struct {
-	basePath    string
-	certsCache  map[string]*CertResponse
-	restyClient *resty.Client
-	Config      struct {
-		CertsInvalidateTime time.Duration
+	basePath           string
+	adminBasePath      string
+	relativePath       string
+	defaultRealm       string
+	tlsConfig          *tls.Config
+	caBundles          [][]byte
+	timeout            time.Duration
+	connectTimeout     time.Duration
+	proxyURL           string
+	userAgent          string
+	logger             Logger
+	debug              bool
+	retryPolicy        *RetryPolicy
+	rateLimit          *RateLimit
+	endpointRateLimits map[EndpointClass]RateLimit
+	instrumentations   []Instrumentation
+	certsCache         *certsCache
+	introspection      *introspectionCache
+	discovery          discoveryCache
+	certsStore         CertsStore
+	realmSecrets       map[string][]byte
+	realmSecretsMu     sync.RWMutex
+	authenticators     map[string]ClientAuthenticator
+	authenticatorsMu   sync.RWMutex
+	restyClient        *resty.Client
+	// now and wait are the clock of the device flow, replaced in tests
+	now    func() time.Time
+	wait   func(ctx context.Context, d time.Duration) error
+	Config struct {
+		CertsInvalidateTime  time.Duration
+		CertsRefreshInterval time.Duration
+		CertsPrefetch        bool
+		// the introspection cache is enabled if both the TTL and the size are positive
+		IntrospectionCacheTTL         time.Duration
+		IntrospectionCacheSize        int
+		IntrospectionNegativeCacheTTL time.Duration
+		OpenIDDiscovery               bool
 	}
 }
 
@@ -26,8 +63,11 @@ const (
 	urlSeparator  string = "/"
 )
 
-var authAdminRealms = makeURL("auth", "admin", "realms")
-var authRealms = makeURL("auth", "realms")
+// defaultRelativePath is the context path of keycloak before version 17
+const defaultRelativePath = "auth"
+
+var adminRealmsPath = makeURL("admin", "realms")
+var realmsPath = "realms"
 var tokenEndpoint = makeURL("protocol", "openid-connect", "token")
 var logoutEndpoint = makeURL("protocol", "openid-connect", "logout")
 var openIDConnect = makeURL("protocol", "openid-connect")
@@ -36,29 +76,76 @@ func makeURL(path ...string) string {
 	return strings.Join(path, urlSeparator)
 }
 
-func (client *gocloak) getRequest() *resty.Request {
+// getRequest builds a request of the operation in the realm, or in the default realm if the realm is empty
+func (client *gocloak) getRequest(ctx context.Context, operation, realm string) *resty.Request {
+	return client.newRequest(ctx, operation, client.getRealm(realm))
+}
+
+// newRequest builds a request of the operation, which is the name of the client method sending it.
+// The realm is reported to the instrumentations, it is empty for requests which do not belong to a realm
+func (client *gocloak) newRequest(ctx context.Context, operation, realm string) *resty.Request {
+	if client.retryPolicy != nil {
+		ctx = withRetryState(ctx)
+	}
+	ctx = client.withRequestState(ctx, operation, realm)
 	var err HTTPErrorResponse
-	return client.restyClient.R().SetError(&err)
+	return client.restyClient.R().
+		SetContext(ctx).
+		SetError(&err)
 }
 
-func (client *gocloak) getRequestWithBearerAuth(token string) *resty.Request {
-	return client.getRequest().
+func (client *gocloak) getRequestWithBearerAuth(ctx context.Context, operation, realm, token string) *resty.Request {
+	return withBearerAuth(client.getRequest(ctx, operation, realm), token)
+}
+
+func withBearerAuth(req *resty.Request, token string) *resty.Request {
+	return req.
 		SetAuthToken(token).
 		SetHeader("Content-Type", "application/json")
 }
 
-func (client *gocloak) getRequestWithBasicAuth(clientID string, clientSecret string) *resty.Request {
-	req := client.getRequest().
+// getRequestWithClientAuth authenticates the client with the given authenticator, the one set for the client
+// or, if a secret is given, with client_secret_basic
+func (client *gocloak) getRequestWithClientAuth(ctx context.Context, operation, realm, clientID, clientSecret string, authenticator ClientAuthenticator) (*resty.Request, error) {
+	req := client.getRequest(ctx, operation, realm).
 		SetHeader("Content-Type", "application/x-www-form-urlencoded")
+	if authenticator == nil {
+		authenticator = client.getClientAuthenticator(realm, clientID)
+	}
 	// Public client doesn't require Basic Auth
-	if len(clientID) > 0 && len(clientSecret) > 0 {
-		httpBasicAuth := base64.URLEncoding.EncodeToString([]byte(clientID + ":" + clientSecret))
-		req.SetHeader("Authorization", "Basic "+httpBasicAuth)
+	if authenticator == nil && len(clientID) > 0 && len(clientSecret) > 0 {
+		authenticator = NewClientSecretBasicAuthenticator(clientSecret)
+	}
+	if authenticator != nil {
+		var audience string
+		if needsAudience(authenticator) {
+			var err error
+			if audience, err = client.getEndpointURL(ctx, realm, issuerURL); err != nil {
+				return nil, err
+			}
+		}
+		if err := authenticator.Authenticate(req, clientID, audience); err != nil {
+			return nil, err
+		}
+		if state := getRetryState(req.Context()); state != nil {
+			state.authenticate = func(req *resty.Request) error {
+				return authenticator.Authenticate(req, clientID, audience)
+			}
+		}
 	}
-	return req
+	return req, nil
 }
 
+// checkForError returns the error of the request and ends its instrumentation
 func checkForError(resp *resty.Response, err error) error {
+	err = responseError(resp, err)
+	if resp != nil {
+		endRequest(resp.Request, resp, err)
+	}
+	return err
+}
+
+func responseError(resp *resty.Response, err error) error {
 	if err != nil {
 		return err
 	}
@@ -66,19 +153,11 @@ func checkForError(resp *resty.Response, err error) error {
 		return errors.New("empty response")
 	}
 	if resp.IsError() {
-		var msg string
-		e := resp.Error().(*HTTPErrorResponse)
-		if e != nil && len(e.ErrorMessage) > 0 {
-			msg = fmt.Sprintf("%s: %s", resp.Status(), e.ErrorMessage)
-		} else if e != nil && len(e.Error) > 0 {
-			msg = fmt.Sprintf("%s: %s", resp.Status(), e.Error)
-		} else {
-			msg = resp.Status()
+		apiError := newAPIError(resp)
+		if apiError.Code == http.StatusConflict {
+			return &ObjectAlreadyExists{ErrorMessage: apiError.Message, apiError: apiError}
 		}
-		if resp.StatusCode() == 409 {
-			return &ObjectAlreadyExists{ErrorMessage: msg}
-		}
-		return errors.New(msg)
+		return apiError
 	}
 	return nil
 }
@@ -91,7 +170,18 @@ func getID(resp *resty.Response) string {
 
 func findUsedKey(usedKeyID string, keys []*CertResponseKey) *CertResponseKey {
 	for _, key := range keys {
-		if *(key.Kid) == usedKeyID {
+		if PString(key.Kid) == usedKeyID {
+			return key
+		}
+	}
+
+	// keys seeded from a PEM public key have no key id. They are only used for tokens without a key id,
+	// so an unknown key id leads to a refresh of the certs
+	if len(usedKeyID) > 0 {
+		return nil
+	}
+	for _, key := range keys {
+		if NilOrEmpty(key.Kid) {
 			return key
 		}
 	}
@@ -103,41 +193,157 @@ func findUsedKey(usedKeyID string, keys []*CertResponseKey) *CertResponseKey {
 // Keycloak client
 // ===============
 
+// ClientOption configures the client created by NewClient
+type ClientOption func(*gocloak)
+
 // NewClient creates a new Client
-func NewClient(basePath string) GoCloak {
+func NewClient(basePath string, options ...ClientOption) GoCloak {
 	c := gocloak{
-		basePath:    strings.TrimRight(basePath, urlSeparator),
-		certsCache:  make(map[string]*CertResponse),
-		restyClient: resty.New(),
+		basePath:       strings.TrimRight(basePath, urlSeparator),
+		relativePath:   defaultRelativePath,
+		realmSecrets:   make(map[string][]byte),
+		authenticators: make(map[string]ClientAuthenticator),
+		discovery:      discoveryCache{entries: make(map[string]discoveryEntry)},
+		restyClient:    resty.New(),
+		now:            time.Now,
+		wait:           waitContext,
 	}
 	c.Config.CertsInvalidateTime = 10 * time.Minute
+	c.Config.CertsRefreshInterval = 10 * time.Second
+	c.Config.IntrospectionNegativeCacheTTL = defaultIntrospectionNegativeCacheTTL
+
+	for _, option := range options {
+		option(&c)
+	}
+	if len(c.adminBasePath) == 0 {
+		c.adminBasePath = c.basePath
+	}
+	c.basePath = joinRelativePath(c.basePath, c.relativePath)
+	c.adminBasePath = joinRelativePath(c.adminBasePath, c.relativePath)
+	c.configureRestyClient()
+	c.certsCache = newCertsCache(c.getNewCerts, c.Config.CertsInvalidateTime, c.Config.CertsRefreshInterval, c.Config.CertsPrefetch, c.certsStore)
+	if c.Config.IntrospectionCacheTTL > 0 && c.Config.IntrospectionCacheSize > 0 {
+		c.introspection = newIntrospectionCache(c.Config.IntrospectionCacheTTL, c.Config.IntrospectionNegativeCacheTTL, c.Config.IntrospectionCacheSize)
+	}
 
 	return &c
 }
 
+// configureRestyClient applies the options to the resty client, in NewClient and when the resty client is replaced
+func (client *gocloak) configureRestyClient() {
+	client.applyHTTPOptions()
+	client.applyTLSConfig()
+	client.redactDebugLog()
+	// the limits wrap the transport, so they are set after the options which change it
+	client.setRateLimits()
+	if client.retryPolicy != nil {
+		client.setRetryPolicy(client.retryPolicy)
+	}
+	client.instrumentRequests()
+}
+
+// SetAuthRelativePath sets the context path keycloak is served from, "auth" by default.
+// Keycloak 17 and later is served from the root context, which is set with an empty path
+func SetAuthRelativePath(relativePath string) ClientOption {
+	return func(client *gocloak) {
+		client.relativePath = relativePath
+	}
+}
+
+// SetAdminBasePath sets the URL of the admin REST API, if it is served on a different host than the public endpoints
+func SetAdminBasePath(basePath string) ClientOption {
+	return func(client *gocloak) {
+		client.adminBasePath = strings.TrimRight(basePath, urlSeparator)
+	}
+}
+
+// joinRelativePath appends the context path to the base path
+func joinRelativePath(basePath, relativePath string) string {
+	relativePath = strings.Trim(relativePath, urlSeparator)
+	if len(relativePath) == 0 {
+		return basePath
+	}
+	return makeURL(basePath, relativePath)
+}
+
+// SetCertCacheInvalidationTime sets how long the certs are cached if keycloak sends no cache headers
+func SetCertCacheInvalidationTime(duration time.Duration) ClientOption {
+	return func(client *gocloak) {
+		client.Config.CertsInvalidateTime = duration
+	}
+}
+
+// SetCertCacheRefreshInterval sets how often at most the certs are refreshed because of a token signed with an unknown key
+func SetCertCacheRefreshInterval(interval time.Duration) ClientOption {
+	return func(client *gocloak) {
+		client.Config.CertsRefreshInterval = interval
+	}
+}
+
+// SetCertsStore sets the store the fetched certs are saved to and loaded from when the certs endpoint fails
+func SetCertsStore(store CertsStore) ClientOption {
+	return func(client *gocloak) {
+		client.certsStore = store
+	}
+}
+
+// SetCertCachePrefetch enables refreshing the cached certs in the background before they expire
+func SetCertCachePrefetch(enabled bool) ClientOption {
+	return func(client *gocloak) {
+		client.Config.CertsPrefetch = enabled
+	}
+}
+
+// SetIntrospectionCache enables caching the introspection results of at most size tokens.
+// Active tokens are cached until they expire but at most for the TTL
+func SetIntrospectionCache(ttl time.Duration, size int) ClientOption {
+	return func(client *gocloak) {
+		client.Config.IntrospectionCacheTTL = ttl
+		client.Config.IntrospectionCacheSize = size
+	}
+}
+
+// SetIntrospectionNegativeCacheTTL sets how long inactive tokens are cached, 5 seconds by default
+func SetIntrospectionNegativeCacheTTL(ttl time.Duration) ClientOption {
+	return func(client *gocloak) {
+		client.Config.IntrospectionNegativeCacheTTL = ttl
+	}
+}
+
 func (client *gocloak) RestyClient() *resty.Client {
 	return client.restyClient
 }
 
+// SetRestyClient replaces the resty client and applies the options of NewClient to it,
+// e.g. the retry policy, the rate limits and the instrumentations
 func (client *gocloak) SetRestyClient(restyClient *resty.Client) {
+	if restyClient == client.restyClient {
+		return
+	}
 	client.restyClient = restyClient
+	client.configureRestyClient()
 }
 
 func (client *gocloak) getRealmURL(realm string, path ...string) string {
-	path = append([]string{client.basePath, authRealms, realm}, path...)
+	path = append([]string{client.basePath, realmsPath, client.getRealm(realm)}, path...)
 	return makeURL(path...)
 }
 
+// getAdminRealmsURL returns the URL of the realm collection, which does not depend on the default realm
+func (client *gocloak) getAdminRealmsURL() string {
+	return makeURL(client.adminBasePath, adminRealmsPath)
+}
+
 func (client *gocloak) getAdminRealmURL(realm string, path ...string) string {
-	path = append([]string{client.basePath, authAdminRealms, realm}, path...)
+	path = append([]string{client.adminBasePath, adminRealmsPath, client.getRealm(realm)}, path...)
 	return makeURL(path...)
 }
 
-func (client *gocloak) GetServerInfo(accessToken string) (*ServerInfoRepesentation, error) {
+func (client *gocloak) GetServerInfo(ctx context.Context, accessToken string) (*ServerInfoRepesentation, error) {
 	var result ServerInfoRepesentation
-	resp, err := client.getRequestWithBearerAuth(accessToken).
+	resp, err := withBearerAuth(client.newRequest(ctx, "GetServerInfo", ""), accessToken).
 		SetResult(&result).
-		Get(makeURL(client.basePath, "auth", "admin", "serverinfo"))
+		Get(makeURL(client.adminBasePath, "admin", "serverinfo"))
 
 	if err := checkForError(resp, err); err != nil {
 		return nil, err
@@ -147,11 +353,16 @@ func (client *gocloak) GetServerInfo(accessToken string) (*ServerInfoRepesentati
 }
 
 // GetUserInfo calls the UserInfo endpoint
-func (client *gocloak) GetUserInfo(accessToken string, realm string) (*UserInfo, error) {
+func (client *gocloak) GetUserInfo(ctx context.Context, accessToken string, realm string) (*UserInfo, error) {
+	endpointURL, err := client.getEndpointURL(ctx, realm, userInfoURL)
+	if err != nil {
+		return nil, err
+	}
+
 	var result UserInfo
-	resp, err := client.getRequestWithBearerAuth(accessToken).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetUserInfo", realm, accessToken).
 		SetResult(&result).
-		Get(client.getRealmURL(realm, openIDConnect, "userinfo"))
+		Get(endpointURL)
 
 	if err := checkForError(resp, err); err != nil {
 		return nil, err
@@ -160,41 +371,69 @@ func (client *gocloak) GetUserInfo(accessToken string, realm string) (*UserInfo,
 	return &result, nil
 }
 
-func (client *gocloak) getNewCerts(realm string) (*CertResponse, error) {
+func (client *gocloak) getNewCerts(ctx context.Context, realm string) (*CertResponse, http.Header, error) {
+	endpointURL, err := client.getEndpointURL(ctx, realm, jwksURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var result CertResponse
-	resp, err := client.getRequest().
+	resp, err := client.getRequest(ctx, "GetCerts", realm).
 		SetResult(&result).
-		Get(client.getRealmURL(realm, openIDConnect, "certs"))
+		Get(endpointURL)
 
 	if err := checkForError(resp, err); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return &result, nil
+	return &result, resp.Header(), nil
 }
 
-// GetCerts fetches certificates for the given realm from the public /open-id-connect/certs endpoint
-func (client *gocloak) GetCerts(realm string) (*CertResponse, error) {
-	if cert, ok := client.certsCache[realm]; ok {
-		return cert, nil
-	}
-	cert, err := client.getNewCerts(realm)
+// GetCerts fetches certificates for the given realm from the public /open-id-connect/certs endpoint.
+// The certificates are cached for the lifetime given by the cache headers or CertsInvalidateTime
+func (client *gocloak) GetCerts(ctx context.Context, realm string) (*CertResponse, error) {
+	return client.certsCache.get(ctx, client.getRealm(realm))
+}
+
+// SetCerts seeds the certs cache of the realm, e.g. with a JWKS document read from disk,
+// so tokens can be verified without a request to the certs endpoint
+func (client *gocloak) SetCerts(realm string, certs *CertResponse) {
+	client.certsCache.seed(client.getRealm(realm), certs)
+}
+
+// SetRealmPublicKey seeds the certs cache of the realm with a PEM or base64 DER encoded public key,
+// e.g. the public_key of the IssuerResponse. The key has no key id, so it only verifies tokens without a kid
+func (client *gocloak) SetRealmPublicKey(realm string, publicKey string) error {
+	key, err := parsePublicKey(publicKey)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	client.certsCache[realm] = cert
-	timer := time.NewTimer(client.Config.CertsInvalidateTime)
-	go func() {
-		<-timer.C
-		delete(client.certsCache, realm)
-	}()
-	return cert, nil
+	client.certsCache.seed(client.getRealm(realm), &CertResponse{Keys: []*CertResponseKey{key}})
+	return nil
+}
+
+// GetCertsCacheStats returns the counters of the certs cache
+func (client *gocloak) GetCertsCacheStats() CertsCacheStats {
+	return client.certsCache.getStats()
+}
+
+// Close stops the background prefetching of the certs and cancels the requests of the certs in flight
+func (client *gocloak) Close() {
+	client.certsCache.close()
+}
+
+// GetIntrospectionCacheStats returns the counters of the introspection cache, which are zero if the cache is disabled
+func (client *gocloak) GetIntrospectionCacheStats() IntrospectionCacheStats {
+	if client.introspection == nil {
+		return IntrospectionCacheStats{}
+	}
+	return client.introspection.getStats()
 }
 
 // GetIssuer gets the issuer of the given realm
-func (client *gocloak) GetIssuer(realm string) (*IssuerResponse, error) {
+func (client *gocloak) GetIssuer(ctx context.Context, realm string) (*IssuerResponse, error) {
 	var result IssuerResponse
-	resp, err := client.getRequest().
+	resp, err := client.getRequest(ctx, "GetIssuer", realm).
 		SetResult(&result).
 		Get(client.getRealmURL(realm))
 
@@ -206,74 +445,176 @@ func (client *gocloak) GetIssuer(realm string) (*IssuerResponse, error) {
 }
 
 // RetrospectToken calls the openid-connect introspect endpoint
-func (client *gocloak) RetrospectToken(accessToken string, clientID, clientSecret string, realm string) (*RetrospecTokenResult, error) {
-	var result RetrospecTokenResult
-	resp, err := client.getRequestWithBasicAuth(clientID, clientSecret).
-		SetFormData(map[string]string{
-			"token_type_hint": "requesting_party_token",
-			"token":           accessToken,
-		}).
-		SetResult(&result).
-		Post(client.getRealmURL(realm, tokenEndpoint, "introspect"))
+func (client *gocloak) RetrospectToken(ctx context.Context, accessToken string, clientID, clientSecret string, realm string) (*RetrospecTokenResult, error) {
+	return client.introspectToken(ctx, "RetrospectToken", clientID, clientSecret, realm, accessToken, TokenTypeHintRequestingPartyToken)
+}
 
-	if err := checkForError(resp, err); err != nil {
+// IntrospectToken calls the openid-connect introspect endpoint with the given token type hint, which may be empty.
+// The results are served from the introspection cache if it is enabled
+func (client *gocloak) IntrospectToken(ctx context.Context, clientID, clientSecret, realm, token, tokenTypeHint string) (*RetrospecTokenResult, error) {
+	return client.introspectToken(ctx, "IntrospectToken", clientID, clientSecret, realm, token, tokenTypeHint)
+}
+
+func (client *gocloak) introspectToken(ctx context.Context, operation, clientID, clientSecret, realm, token, tokenTypeHint string) (*RetrospecTokenResult, error) {
+	var key introspectionKey
+	if client.introspection != nil {
+		key = introspectionCacheKey(realm, clientID, tokenTypeHint, token)
+		if result, ok := client.introspection.get(key); ok {
+			return result, nil
+		}
+	}
+
+	var result RetrospecTokenResult
+	if err := client.introspectTokenCustomClaims(ctx, operation, clientID, clientSecret, realm, token, tokenTypeHint, &result); err != nil {
 		return nil, err
 	}
+	if client.introspection != nil {
+		client.introspection.set(key, &result)
+	}
 
 	return &result, nil
 }
 
-// DecodeAccessToken decodes the accessToken
-func (client *gocloak) DecodeAccessToken(accessToken, realm string) (*jwt.Token, *jwt.MapClaims, error) {
-	decodedHeader, err := jwx.DecodeAccessTokenHeader(accessToken)
+// IntrospectTokenCustomClaims calls the openid-connect introspect endpoint and decodes the response into the claims
+func (client *gocloak) IntrospectTokenCustomClaims(ctx context.Context, clientID, clientSecret, realm, token, tokenTypeHint string, claims interface{}) error {
+	return client.introspectTokenCustomClaims(ctx, "IntrospectTokenCustomClaims", clientID, clientSecret, realm, token, tokenTypeHint, claims)
+}
+
+func (client *gocloak) introspectTokenCustomClaims(ctx context.Context, operation, clientID, clientSecret, realm, token, tokenTypeHint string, claims interface{}) error {
+	req, err := client.getRequestWithClientAuth(ctx, operation, realm, clientID, clientSecret, nil)
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
 
-	certResult, err := client.GetCerts(realm)
+	endpointURL, err := client.getEndpointURL(ctx, realm, introspectionURL)
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
 
-	usedKey := findUsedKey(decodedHeader.Kid, certResult.Keys)
-	if usedKey == nil {
-		return nil, nil, errors.New("cannot find a key to decode the token")
+	formData := map[string]string{
+		"token": token,
 	}
+	if len(tokenTypeHint) > 0 {
+		formData["token_type_hint"] = tokenTypeHint
+	}
+	resp, err := req.SetFormData(formData).
+		SetResult(claims).
+		Post(endpointURL)
+
+	return checkForError(resp, err)
+}
 
-	return jwx.DecodeAccessToken(accessToken, usedKey.E, usedKey.N)
+// DecodeAccessToken decodes the accessToken
+func (client *gocloak) DecodeAccessToken(ctx context.Context, accessToken, realm string, options ...jwx.ValidationOption) (*jwt.Token, *jwt.MapClaims, error) {
+	claims := &jwt.MapClaims{}
+	token, err := client.DecodeAccessTokenCustomClaims(ctx, accessToken, realm, claims, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return token, claims, nil
 }
 
-// DecodeAccessTokenCustomClaims decodes the accessToken and writes claims into the given claims
-func (client *gocloak) DecodeAccessTokenCustomClaims(accessToken string, realm string, claims jwt.Claims) (*jwt.Token, error) {
+// DecodeAccessTokenCustomClaims decodes the accessToken and writes claims into the given claims.
+// If validation options are given, the issuer of the token is checked against the realm URL by default
+func (client *gocloak) DecodeAccessTokenCustomClaims(ctx context.Context, accessToken string, realm string, claims jwt.Claims, options ...jwx.ValidationOption) (*jwt.Token, error) {
 	decodedHeader, err := jwx.DecodeAccessTokenHeader(accessToken)
 	if err != nil {
 		return nil, err
 	}
 
-	certResult, err := client.GetCerts(realm)
+	if len(options) > 0 {
+		issuer, err := client.getEndpointURL(ctx, realm, issuerURL)
+		if err != nil {
+			return nil, err
+		}
+		options = append([]jwx.ValidationOption{jwx.WithIssuer(issuer)}, options...)
+	}
+
+	// HMAC signed tokens are verified with the realm secret only, never with a public key
+	if strings.HasPrefix(decodedHeader.Alg, "HS") {
+		secret := client.getRealmSecret(realm)
+		if secret == nil {
+			return nil, fmt.Errorf("no secret is set to verify a %s token of the realm %s", decodedHeader.Alg, realm)
+		}
+		return jwx.DecodeAccessTokenHMACCustomClaims(accessToken, secret, claims, options...)
+	}
+
+	certResult, err := client.GetCerts(ctx, realm)
 	if err != nil {
 		return nil, err
 	}
 
 	usedKey := findUsedKey(decodedHeader.Kid, certResult.Keys)
+	if usedKey == nil {
+		// the keys may have been rotated since the certs were cached
+		certResult, err = client.certsCache.refresh(ctx, client.getRealm(realm))
+		if err != nil {
+			return nil, err
+		}
+		usedKey = findUsedKey(decodedHeader.Kid, certResult.Keys)
+	}
 	if usedKey == nil {
 		return nil, errors.New("cannot find a key to decode the token")
 	}
+	if !NilOrEmpty(usedKey.Alg) && *(usedKey.Alg) != decodedHeader.Alg {
+		return nil, fmt.Errorf("the token algorithm %s does not match the key algorithm %s", decodedHeader.Alg, *(usedKey.Alg))
+	}
 
-	return jwx.DecodeAccessTokenCustomClaims(accessToken, usedKey.E, usedKey.N, claims)
+	switch PString(usedKey.Kty) {
+	case "RSA":
+		return jwx.DecodeAccessTokenRSACustomClaims(accessToken, usedKey.E, usedKey.N, claims, options...)
+	case "EC":
+		return jwx.DecodeAccessTokenECDSACustomClaims(accessToken, usedKey.X, usedKey.Y, usedKey.Crv, claims, options...)
+	}
+	return nil, fmt.Errorf("unsupported key type: %s", PString(usedKey.Kty))
 }
 
-func (client *gocloak) GetToken(realm string, options TokenOptions) (*JWT, error) {
-	var token JWT
-	var req *resty.Request
-	if !NilOrEmpty(options.ClientSecret) {
-		req = client.getRequestWithBasicAuth(*(options.ClientID), *(options.ClientSecret))
-	} else {
-		req = client.getRequest()
+// SetRealmSecret sets the secret used to verify HMAC (HS256, HS384, HS512) signed tokens of the realm
+func (client *gocloak) SetRealmSecret(realm string, secret []byte) {
+	client.realmSecretsMu.Lock()
+	defer client.realmSecretsMu.Unlock()
+	client.realmSecrets[client.getRealm(realm)] = secret
+}
+
+func (client *gocloak) getRealmSecret(realm string) []byte {
+	client.realmSecretsMu.RLock()
+	defer client.realmSecretsMu.RUnlock()
+	return client.realmSecrets[client.getRealm(realm)]
+}
+
+// SetClientAuthenticator sets how the client of the realm authenticates at the token, introspection and logout endpoints,
+// e.g. with private_key_jwt instead of the client secret
+func (client *gocloak) SetClientAuthenticator(realm, clientID string, authenticator ClientAuthenticator) {
+	client.authenticatorsMu.Lock()
+	defer client.authenticatorsMu.Unlock()
+	client.authenticators[makeURL(client.getRealm(realm), clientID)] = authenticator
+}
+
+func (client *gocloak) getClientAuthenticator(realm, clientID string) ClientAuthenticator {
+	client.authenticatorsMu.RLock()
+	defer client.authenticatorsMu.RUnlock()
+	return client.authenticators[makeURL(client.getRealm(realm), clientID)]
+}
+
+func (client *gocloak) GetToken(ctx context.Context, realm string, options TokenOptions) (*JWT, error) {
+	return client.getToken(ctx, "GetToken", realm, options)
+}
+
+// getToken requests a token for the operation, so the requests of the login methods are named after them
+func (client *gocloak) getToken(ctx context.Context, operation, realm string, options TokenOptions) (*JWT, error) {
+	req, err := client.getRequestWithClientAuth(ctx, operation, realm, PString(options.ClientID), PString(options.ClientSecret), options.ClientAuthenticator)
+	if err != nil {
+		return nil, err
 	}
+	endpointURL, err := client.getEndpointURL(ctx, realm, tokenURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var token JWT
 	resp, err := req.SetFormData(options.FormData()).
 		SetResult(&token).
-		Post(client.getRealmURL(realm, tokenEndpoint))
+		Post(endpointURL)
 
 	if err := checkForError(resp, err); err != nil {
 		return nil, err
@@ -283,8 +624,8 @@ func (client *gocloak) GetToken(realm string, options TokenOptions) (*JWT, error
 }
 
 // RefreshToken refreshes the given token
-func (client *gocloak) RefreshToken(refreshToken, clientID, clientSecret, realm string) (*JWT, error) {
-	return client.GetToken(realm, TokenOptions{
+func (client *gocloak) RefreshToken(ctx context.Context, refreshToken, clientID, clientSecret, realm string) (*JWT, error) {
+	return client.getToken(ctx, "RefreshToken", realm, TokenOptions{
 		ClientID:     &clientID,
 		ClientSecret: &clientSecret,
 		GrantType:    StringP("refresh_token"),
@@ -293,8 +634,8 @@ func (client *gocloak) RefreshToken(refreshToken, clientID, clientSecret, realm
 }
 
 // LoginAdmin performs a login with Admin client
-func (client *gocloak) LoginAdmin(username, password, realm string) (*JWT, error) {
-	return client.GetToken(realm, TokenOptions{
+func (client *gocloak) LoginAdmin(ctx context.Context, username, password, realm string) (*JWT, error) {
+	return client.getToken(ctx, "LoginAdmin", realm, TokenOptions{
 		ClientID:  StringP(adminClientID),
 		GrantType: StringP("password"),
 		Username:  &username,
@@ -303,8 +644,8 @@ func (client *gocloak) LoginAdmin(username, password, realm string) (*JWT, error
 }
 
 // Login performs a login with client credentials
-func (client *gocloak) LoginClient(clientID, clientSecret, realm string) (*JWT, error) {
-	return client.GetToken(realm, TokenOptions{
+func (client *gocloak) LoginClient(ctx context.Context, clientID, clientSecret, realm string) (*JWT, error) {
+	return client.getToken(ctx, "LoginClient", realm, TokenOptions{
 		ClientID:     &clientID,
 		ClientSecret: &clientSecret,
 		GrantType:    StringP("client_credentials"),
@@ -312,8 +653,8 @@ func (client *gocloak) LoginClient(clientID, clientSecret, realm string) (*JWT,
 }
 
 // Login performs a login with user credentials and a client
-func (client *gocloak) Login(clientID, clientSecret, realm, username, password string) (*JWT, error) {
-	return client.GetToken(realm, TokenOptions{
+func (client *gocloak) Login(ctx context.Context, clientID, clientSecret, realm, username, password string) (*JWT, error) {
+	return client.getToken(ctx, "Login", realm, TokenOptions{
 		ClientID:     &clientID,
 		ClientSecret: &clientSecret,
 		GrantType:    StringP("password"),
@@ -322,32 +663,126 @@ func (client *gocloak) Login(clientID, clientSecret, realm, username, password s
 	})
 }
 
+// GetAuthCodeURL returns the URL of the authorization endpoint the user is redirected to in the authorization code flow.
+// The client ID is required, the scope defaults to openid and the response type to code
+func (client *gocloak) GetAuthCodeURL(realm string, options AuthCodeURLOptions) (string, error) {
+	if NilOrEmpty(options.ClientID) {
+		return "", errors.New("ID of a client required")
+	}
+	if len(options.Scopes) > 0 {
+		options.Scope = StringP(strings.Join(options.Scopes, " "))
+	}
+	if NilOrEmpty(options.Scope) {
+		options.Scope = StringP("openid")
+	}
+	if NilOrEmpty(options.ResponseType) {
+		options.ResponseType = StringP("code")
+	}
+
+	params, err := GetQueryParams(options)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	for key, value := range params {
+		query.Set(key, value)
+	}
+
+	return client.getRealmURL(realm, openIDConnect, "auth") + "?" + query.Encode(), nil
+}
+
+// ExchangeCode exchanges the authorization code for a token.
+// If a nonce is given, the returned ID token is verified and its nonce is checked
+func (client *gocloak) ExchangeCode(ctx context.Context, realm string, options TokenOptions, nonce string) (*JWT, error) {
+	if NilOrEmpty(options.GrantType) {
+		options.GrantType = StringP("authorization_code")
+	}
+	token, err := client.getToken(ctx, "ExchangeCode", realm, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) == 0 {
+		return token, nil
+	}
+
+	if len(token.IDToken) == 0 {
+		return nil, errors.New("no ID token has been returned, the openid scope is missing")
+	}
+	validation := []jwx.ValidationOption{jwx.WithTokenTypes("ID"), jwx.WithNonce(nonce)}
+	if !NilOrEmpty(options.ClientID) {
+		validation = append(validation, jwx.WithAudiences(*(options.ClientID)))
+	}
+	if _, err := client.DecodeAccessTokenCustomClaims(ctx, token.IDToken, realm, &jwt.MapClaims{}, validation...); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
 // Logout logs out users with refresh token
-func (client *gocloak) Logout(clientID, clientSecret, realm, refreshToken string) error {
-	resp, err := client.getRequestWithBasicAuth(clientID, clientSecret).
-		SetFormData(map[string]string{
-			"client_id":     clientID,
-			"refresh_token": refreshToken,
-		}).
-		Post(client.getRealmURL(realm, logoutEndpoint))
+func (client *gocloak) Logout(ctx context.Context, clientID, clientSecret, realm, refreshToken string) error {
+	req, err := client.getRequestWithClientAuth(ctx, "Logout", realm, clientID, clientSecret, nil)
+	if err != nil {
+		return err
+	}
+	endpointURL, err := client.getEndpointURL(ctx, realm, logoutURL)
+	if err != nil {
+		return err
+	}
+
+	resp, err := req.SetFormData(map[string]string{
+		"client_id":     clientID,
+		"refresh_token": refreshToken,
+	}).
+		Post(endpointURL)
 
 	return checkForError(resp, err)
 }
 
-func (client *gocloak) LogoutPublicClient(clientID, realm, accessToken, refreshToken string) error {
-	resp, err := client.getRequestWithBearerAuth(accessToken).
+func (client *gocloak) LogoutPublicClient(ctx context.Context, clientID, realm, accessToken, refreshToken string) error {
+	endpointURL, err := client.getEndpointURL(ctx, realm, logoutURL)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.getRequestWithBearerAuth(ctx, "LogoutPublicClient", realm, accessToken).
 		SetFormData(map[string]string{
 			"client_id":     clientID,
 			"refresh_token": refreshToken,
 		}).
-		Post(client.getRealmURL(realm, logoutEndpoint))
+		Post(endpointURL)
+
+	return checkForError(resp, err)
+}
+
+// RevokeToken revokes a refresh or an offline token, or an access token, at the revocation endpoint (RFC 7009).
+// The token type hint is "refresh_token" or "access_token" and may be empty
+func (client *gocloak) RevokeToken(ctx context.Context, clientID, clientSecret, realm, token, tokenTypeHint string) error {
+	req, err := client.getRequestWithClientAuth(ctx, "RevokeToken", realm, clientID, clientSecret, nil)
+	if err != nil {
+		return err
+	}
+	endpointURL, err := client.getEndpointURL(ctx, realm, revocationURL)
+	if err != nil {
+		return err
+	}
+
+	formData := map[string]string{
+		"client_id": clientID,
+		"token":     token,
+	}
+	if len(tokenTypeHint) > 0 {
+		formData["token_type_hint"] = tokenTypeHint
+	}
+	resp, err := req.SetFormData(formData).
+		Post(endpointURL)
 
 	return checkForError(resp, err)
 }
 
 // RequestPermission request a permission
-func (client *gocloak) RequestPermission(clientID, clientSecret, realm, username, password string, permission string) (*JWT, error) {
-	return client.GetToken(realm, TokenOptions{
+func (client *gocloak) RequestPermission(ctx context.Context, clientID, clientSecret, realm, username, password string, permission string) (*JWT, error) {
+	return client.getToken(ctx, "RequestPermission", realm, TokenOptions{
 		ClientID:     &clientID,
 		ClientSecret: &clientSecret,
 		GrantType:    StringP("password"),
@@ -357,13 +792,25 @@ func (client *gocloak) RequestPermission(clientID, clientSecret, realm, username
 	})
 }
 
+// ExchangeToken exchanges a token (RFC 8693), e.g. for a token of another audience.
+// The subject token type defaults to an access token.
+// Tokens of an identity provider are exchanged by setting its alias as SubjectIssuer,
+// users are impersonated by setting their ID or username as RequestedSubject
+func (client *gocloak) ExchangeToken(ctx context.Context, realm string, options TokenOptions) (*JWT, error) {
+	options.GrantType = StringP("urn:ietf:params:oauth:grant-type:token-exchange")
+	if !NilOrEmpty(options.SubjectToken) && NilOrEmpty(options.SubjectTokenType) {
+		options.SubjectTokenType = StringP(TokenTypeAccessToken)
+	}
+	return client.getToken(ctx, "ExchangeToken", realm, options)
+}
+
 // ExecuteActionsEmail executes an actions email
-func (client *gocloak) ExecuteActionsEmail(token, realm string, params ExecuteActionsEmail) error {
+func (client *gocloak) ExecuteActionsEmail(ctx context.Context, token, realm string, params ExecuteActionsEmail) error {
 	queryParams, err := GetQueryParams(params)
 	if err != nil {
 		return err
 	}
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "ExecuteActionsEmail", realm, token).
 		SetBody(params.Actions).
 		SetQueryParams(queryParams).
 		Put(client.getAdminRealmURL(realm, "users", *(params.UserID), "execute-actions-email"))
@@ -371,8 +818,8 @@ func (client *gocloak) ExecuteActionsEmail(token, realm string, params ExecuteAc
 	return checkForError(resp, err)
 }
 
-func (client *gocloak) CreateGroup(token, realm string, group Group) (string, error) {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) CreateGroup(ctx context.Context, token, realm string, group Group) (string, error) {
+	resp, err := client.getRequestWithBearerAuth(ctx, "CreateGroup", realm, token).
 		SetBody(group).
 		Post(client.getAdminRealmURL(realm, "groups"))
 
@@ -383,8 +830,8 @@ func (client *gocloak) CreateGroup(token, realm string, group Group) (string, er
 }
 
 // CreateChildGroup creates a new child group
-func (client *gocloak) CreateChildGroup(token string, realm string, groupID string, group Group) (string, error) {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) CreateChildGroup(ctx context.Context, token string, realm string, groupID string, group Group) (string, error) {
+	resp, err := client.getRequestWithBearerAuth(ctx, "CreateChildGroup", realm, token).
 		SetBody(group).
 		Post(client.getAdminRealmURL(realm, "groups", groupID, "children"))
 
@@ -394,8 +841,8 @@ func (client *gocloak) CreateChildGroup(token string, realm string, groupID stri
 	return getID(resp), nil
 }
 
-func (client *gocloak) CreateComponent(token, realm string, component Component) (string, error) {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) CreateComponent(ctx context.Context, token, realm string, component Component) (string, error) {
+	resp, err := client.getRequestWithBearerAuth(ctx, "CreateComponent", realm, token).
 		SetBody(component).
 		Post(client.getAdminRealmURL(realm, "components"))
 
@@ -405,8 +852,8 @@ func (client *gocloak) CreateComponent(token, realm string, component Component)
 	return getID(resp), nil
 }
 
-func (client *gocloak) CreateClient(token, realm string, newClient Client) (string, error) {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) CreateClient(ctx context.Context, token, realm string, newClient Client) (string, error) {
+	resp, err := client.getRequestWithBearerAuth(ctx, "CreateClient", realm, token).
 		SetBody(newClient).
 		Post(client.getAdminRealmURL(realm, "clients"))
 
@@ -417,8 +864,8 @@ func (client *gocloak) CreateClient(token, realm string, newClient Client) (stri
 }
 
 // CreateClientRole creates a new role for a client
-func (client *gocloak) CreateClientRole(token, realm, clientID string, role Role) (string, error) {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) CreateClientRole(ctx context.Context, token, realm, clientID string, role Role) (string, error) {
+	resp, err := client.getRequestWithBearerAuth(ctx, "CreateClientRole", realm, token).
 		SetBody(role).
 		Post(client.getAdminRealmURL(realm, "clients", clientID, "roles"))
 
@@ -429,8 +876,8 @@ func (client *gocloak) CreateClientRole(token, realm, clientID string, role Role
 }
 
 // CreateClientScope creates a new client scope
-func (client *gocloak) CreateClientScope(token, realm string, scope ClientScope) (string, error) {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) CreateClientScope(ctx context.Context, token, realm string, scope ClientScope) (string, error) {
+	resp, err := client.getRequestWithBearerAuth(ctx, "CreateClientScope", realm, token).
 		SetBody(scope).
 		Post(client.getAdminRealmURL(realm, "client-scopes"))
 
@@ -440,11 +887,11 @@ func (client *gocloak) CreateClientScope(token, realm string, scope ClientScope)
 	return getID(resp), nil
 }
 
-func (client *gocloak) UpdateGroup(token, realm string, updatedGroup Group) error {
+func (client *gocloak) UpdateGroup(ctx context.Context, token, realm string, updatedGroup Group) error {
 	if NilOrEmpty(updatedGroup.ID) {
 		return errors.New("ID of a group required")
 	}
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "UpdateGroup", realm, token).
 		SetBody(updatedGroup).
 		Put(client.getAdminRealmURL(realm, "groups", PString(updatedGroup.ID)))
 
@@ -452,75 +899,75 @@ func (client *gocloak) UpdateGroup(token, realm string, updatedGroup Group) erro
 }
 
 // UpdateClient updates the given Client
-func (client *gocloak) UpdateClient(token, realm string, updatedClient Client) error {
+func (client *gocloak) UpdateClient(ctx context.Context, token, realm string, updatedClient Client) error {
 	if NilOrEmpty(updatedClient.ID) {
 		return errors.New("ID of a client required")
 	}
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "UpdateClient", realm, token).
 		SetBody(updatedClient).
 		Put(client.getAdminRealmURL(realm, "clients", PString(updatedClient.ID)))
 
 	return checkForError(resp, err)
 }
 
-func (client *gocloak) UpdateRole(token, realm, clientID string, role Role) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) UpdateRole(ctx context.Context, token, realm, clientID string, role Role) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "UpdateRole", realm, token).
 		SetBody(role).
 		Put(client.getAdminRealmURL(realm, "clients", clientID, "roles", PString(role.Name)))
 
 	return checkForError(resp, err)
 }
 
-func (client *gocloak) UpdateClientScope(token string, realm string, scope ClientScope) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) UpdateClientScope(ctx context.Context, token string, realm string, scope ClientScope) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "UpdateClientScope", realm, token).
 		SetBody(scope).
 		Put(client.getAdminRealmURL(realm, "client-scopes", PString(scope.ID)))
 
 	return checkForError(resp, err)
 }
 
-func (client *gocloak) DeleteGroup(token string, realm string, groupID string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) DeleteGroup(ctx context.Context, token string, realm string, groupID string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "DeleteGroup", realm, token).
 		Delete(client.getAdminRealmURL(realm, "groups", groupID))
 
 	return checkForError(resp, err)
 }
 
 // DeleteClient deletes a given client
-func (client *gocloak) DeleteClient(token string, realm string, clientID string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) DeleteClient(ctx context.Context, token string, realm string, clientID string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "DeleteClient", realm, token).
 		Delete(client.getAdminRealmURL(realm, "clients", clientID))
 
 	return checkForError(resp, err)
 }
 
-func (client *gocloak) DeleteComponent(token string, realm string, componentID string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) DeleteComponent(ctx context.Context, token string, realm string, componentID string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "DeleteComponent", realm, token).
 		Delete(client.getAdminRealmURL(realm, "components", componentID))
 
 	return checkForError(resp, err)
 }
 
 // DeleteClientRole deletes a given role
-func (client *gocloak) DeleteClientRole(token, realm, clientID, roleName string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) DeleteClientRole(ctx context.Context, token, realm, clientID, roleName string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "DeleteClientRole", realm, token).
 		Delete(client.getAdminRealmURL(realm, "clients", clientID, "roles", roleName))
 
 	return checkForError(resp, err)
 }
 
-func (client *gocloak) DeleteClientScope(token string, realm string, scopeID string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) DeleteClientScope(ctx context.Context, token string, realm string, scopeID string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "DeleteClientScope", realm, token).
 		Delete(client.getAdminRealmURL(realm, "client-scopes", scopeID))
 
 	return checkForError(resp, err)
 }
 
 // GetClient returns a client
-func (client *gocloak) GetClient(token string, realm string, clientID string) (*Client, error) {
+func (client *gocloak) GetClient(ctx context.Context, token string, realm string, clientID string) (*Client, error) {
 	var result Client
 
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetClient", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "clients", clientID))
 
@@ -532,10 +979,10 @@ func (client *gocloak) GetClient(token string, realm string, clientID string) (*
 }
 
 // GetClientsDefaultScopes returns a list of the client's default scopes
-func (client *gocloak) GetClientsDefaultScopes(token string, realm string, clientID string) ([]*ClientScope, error) {
+func (client *gocloak) GetClientsDefaultScopes(ctx context.Context, token string, realm string, clientID string) ([]*ClientScope, error) {
 	var result []*ClientScope
 
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetClientsDefaultScopes", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "clients", clientID, "default-client-scopes"))
 
@@ -547,26 +994,26 @@ func (client *gocloak) GetClientsDefaultScopes(token string, realm string, clien
 }
 
 // AddDefaultScopeToClient adds a client scope to the list of client's default scopes
-func (client *gocloak) AddDefaultScopeToClient(token string, realm string, clientID string, scopeID string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) AddDefaultScopeToClient(ctx context.Context, token string, realm string, clientID string, scopeID string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "AddDefaultScopeToClient", realm, token).
 		Put(client.getAdminRealmURL(realm, "clients", clientID, "default-client-scopes", scopeID))
 
 	return checkForError(resp, err)
 }
 
 // RemoveDefaultScopeFromClient removes a client scope from the list of client's default scopes
-func (client *gocloak) RemoveDefaultScopeFromClient(token string, realm string, clientID string, scopeID string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) RemoveDefaultScopeFromClient(ctx context.Context, token string, realm string, clientID string, scopeID string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "RemoveDefaultScopeFromClient", realm, token).
 		Delete(client.getAdminRealmURL(realm, "clients", clientID, "default-client-scopes", scopeID))
 
 	return checkForError(resp, err)
 }
 
 // GetClientsOptionalScopes returns a list of the client's optional scopes
-func (client *gocloak) GetClientsOptionalScopes(token string, realm string, clientID string) ([]*ClientScope, error) {
+func (client *gocloak) GetClientsOptionalScopes(ctx context.Context, token string, realm string, clientID string) ([]*ClientScope, error) {
 	var result []*ClientScope
 
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetClientsOptionalScopes", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "clients", clientID, "optional-client-scopes"))
 
@@ -578,26 +1025,26 @@ func (client *gocloak) GetClientsOptionalScopes(token string, realm string, clie
 }
 
 // AddOptionalScopeToClient adds a client scope to the list of client's optional scopes
-func (client *gocloak) AddOptionalScopeToClient(token string, realm string, clientID string, scopeID string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) AddOptionalScopeToClient(ctx context.Context, token string, realm string, clientID string, scopeID string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "AddOptionalScopeToClient", realm, token).
 		Put(client.getAdminRealmURL(realm, "clients", clientID, "optional-client-scopes", scopeID))
 
 	return checkForError(resp, err)
 }
 
 // RemoveOptionalScopeFromClient deletes a client scope from the list of client's optional scopes
-func (client *gocloak) RemoveOptionalScopeFromClient(token string, realm string, clientID string, scopeID string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) RemoveOptionalScopeFromClient(ctx context.Context, token string, realm string, clientID string, scopeID string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "RemoveOptionalScopeFromClient", realm, token).
 		Delete(client.getAdminRealmURL(realm, "clients", clientID, "optional-client-scopes", scopeID))
 
 	return checkForError(resp, err)
 }
 
 // GetDefaultOptionalClientScopes returns a list of default realm optional scopes
-func (client *gocloak) GetDefaultOptionalClientScopes(token string, realm string) ([]*ClientScope, error) {
+func (client *gocloak) GetDefaultOptionalClientScopes(ctx context.Context, token string, realm string) ([]*ClientScope, error) {
 	var result []*ClientScope
 
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetDefaultOptionalClientScopes", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "default-optional-client-scopes"))
 
@@ -609,10 +1056,10 @@ func (client *gocloak) GetDefaultOptionalClientScopes(token string, realm string
 }
 
 // GetDefaultDefaultClientScopes returns a list of default realm default scopes
-func (client *gocloak) GetDefaultDefaultClientScopes(token string, realm string) ([]*ClientScope, error) {
+func (client *gocloak) GetDefaultDefaultClientScopes(ctx context.Context, token string, realm string) ([]*ClientScope, error) {
 	var result []*ClientScope
 
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetDefaultDefaultClientScopes", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "default-default-client-scopes"))
 
@@ -624,10 +1071,10 @@ func (client *gocloak) GetDefaultDefaultClientScopes(token string, realm string)
 }
 
 // GetClientScope returns a clientscope
-func (client *gocloak) GetClientScope(token string, realm string, scopeID string) (*ClientScope, error) {
+func (client *gocloak) GetClientScope(ctx context.Context, token string, realm string, scopeID string) (*ClientScope, error) {
 	var result ClientScope
 
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetClientScope", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "client-scopes", scopeID))
 
@@ -639,10 +1086,10 @@ func (client *gocloak) GetClientScope(token string, realm string, scopeID string
 }
 
 // GetClientScopes returns all client scopes
-func (client *gocloak) GetClientScopes(token string, realm string) ([]*ClientScope, error) {
+func (client *gocloak) GetClientScopes(ctx context.Context, token string, realm string) ([]*ClientScope, error) {
 	var result []*ClientScope
 
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetClientScopes", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "client-scopes"))
 
@@ -655,10 +1102,10 @@ func (client *gocloak) GetClientScopes(token string, realm string) ([]*ClientSco
 
 // Client Scopes -> Mappings -> Get client roles
 // GET /<realm>/client-scopes/59b43ffb-f179-4302-b607-4d2e8a0fa2d3/scope-mappings/clients/3ef54104-04d0-4b75-8a5b-ebdb9be27302
-func (client *gocloak) GetClientScopeMappingClientRoles(token string, realm string, scopeID string, clientID string) ([]*Role, error) {
+func (client *gocloak) GetClientScopeMappingClientRoles(ctx context.Context, token string, realm string, scopeID string, clientID string) ([]*Role, error) {
 	var result []*Role
 
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetClientScopeMappingClientRoles", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "client-scopes", scopeID, "scope-mappings", "clients", clientID))
 
@@ -671,8 +1118,8 @@ func (client *gocloak) GetClientScopeMappingClientRoles(token string, realm stri
 
 // Client Scopes -> Mappings -> Add client role
 // POST /<realm>/client-scopes/59b43ffb-f179-4302-b607-4d2e8a0fa2d3/scope-mappings/clients/3ef54104-04d0-4b75-8a5b-ebdb9be27302
-func (client *gocloak) AddClientScopeMappingClientRoles(token string, realm string, scopeID string, clientID string, roles []*Role) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) AddClientScopeMappingClientRoles(ctx context.Context, token string, realm string, scopeID string, clientID string, roles []*Role) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "AddClientScopeMappingClientRoles", realm, token).
 		SetBody(roles).
 		Post(client.getAdminRealmURL(realm, "client-scopes", scopeID, "scope-mappings", "clients", clientID))
 
@@ -680,9 +1127,9 @@ func (client *gocloak) AddClientScopeMappingClientRoles(token string, realm stri
 }
 
 // GetClientSecret returns a client's secret
-func (client *gocloak) GetClientSecret(token string, realm string, clientID string) (*CredentialRepresentation, error) {
+func (client *gocloak) GetClientSecret(ctx context.Context, token string, realm string, clientID string) (*CredentialRepresentation, error) {
 	var result CredentialRepresentation
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetClientSecret", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "clients", clientID, "client-secret"))
 
@@ -694,9 +1141,9 @@ func (client *gocloak) GetClientSecret(token string, realm string, clientID stri
 }
 
 // GetClientServiceAccount retrieves the service account "user" for a client if enabled
-func (client *gocloak) GetClientServiceAccount(token string, realm string, clientID string) (*User, error) {
+func (client *gocloak) GetClientServiceAccount(ctx context.Context, token string, realm string, clientID string) (*User, error) {
 	var result User
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetClientServiceAccount", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "clients", clientID, "service-account-user"))
 
@@ -707,9 +1154,9 @@ func (client *gocloak) GetClientServiceAccount(token string, realm string, clien
 	return &result, nil
 }
 
-func (client *gocloak) RegenerateClientSecret(token string, realm string, clientID string) (*CredentialRepresentation, error) {
+func (client *gocloak) RegenerateClientSecret(ctx context.Context, token string, realm string, clientID string) (*CredentialRepresentation, error) {
 	var result CredentialRepresentation
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "RegenerateClientSecret", realm, token).
 		SetResult(&result).
 		Post(client.getAdminRealmURL(realm, "clients", clientID, "client-secret"))
 
@@ -720,10 +1167,15 @@ func (client *gocloak) RegenerateClientSecret(token string, realm string, client
 }
 
 // GetClientOfflineSessions returns offline sessions associated with the client
-func (client *gocloak) GetClientOfflineSessions(token, realm, clientID string) ([]*UserSessionRepresentation, error) {
+func (client *gocloak) GetClientOfflineSessions(ctx context.Context, token, realm, clientID string, params ...GetClientUserSessionsParams) ([]*UserSessionRepresentation, error) {
 	var res []*UserSessionRepresentation
-	resp, err := client.getRequestWithBearerAuth(token).
+	queryParams, err := getOptionalQueryParams(params)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetClientOfflineSessions", realm, token).
 		SetResult(&res).
+		SetQueryParams(queryParams).
 		Get(client.getAdminRealmURL(realm, "clients", clientID, "offline-sessions"))
 
 	if err := checkForError(resp, err); err != nil {
@@ -733,10 +1185,15 @@ func (client *gocloak) GetClientOfflineSessions(token, realm, clientID string) (
 }
 
 // GetClientUserSessions returns user sessions associated with the client
-func (client *gocloak) GetClientUserSessions(token, realm, clientID string) ([]*UserSessionRepresentation, error) {
+func (client *gocloak) GetClientUserSessions(ctx context.Context, token, realm, clientID string, params ...GetClientUserSessionsParams) ([]*UserSessionRepresentation, error) {
 	var res []*UserSessionRepresentation
-	resp, err := client.getRequestWithBearerAuth(token).
+	queryParams, err := getOptionalQueryParams(params)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetClientUserSessions", realm, token).
 		SetResult(&res).
+		SetQueryParams(queryParams).
 		Get(client.getAdminRealmURL(realm, "clients", clientID, "user-sessions"))
 
 	if err := checkForError(resp, err); err != nil {
@@ -745,9 +1202,19 @@ func (client *gocloak) GetClientUserSessions(token, realm, clientID string) ([]*
 	return res, nil
 }
 
+// getOptionalQueryParams returns the query parameters of the optional params of a method, a slice of a params struct.
+// Only the first params are used, there are no query parameters if none are given
+func getOptionalQueryParams(params interface{}) (map[string]string, error) {
+	value := reflect.ValueOf(params)
+	if value.Len() == 0 {
+		return nil, nil
+	}
+	return GetQueryParams(value.Index(0).Interface())
+}
+
 // CreateClientProtocolMapper creates a protocol mapper in client scope
-func (client *gocloak) CreateClientProtocolMapper(token, realm, clientID string, mapper ProtocolMapperRepresentation) (string, error) {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) CreateClientProtocolMapper(ctx context.Context, token, realm, clientID string, mapper ProtocolMapperRepresentation) (string, error) {
+	resp, err := client.getRequestWithBearerAuth(ctx, "CreateClientProtocolMapper", realm, token).
 		SetBody(mapper).
 		Post(client.getAdminRealmURL(realm, "clients", clientID, "protocol-mappers", "models"))
 
@@ -758,17 +1225,17 @@ func (client *gocloak) CreateClientProtocolMapper(token, realm, clientID string,
 }
 
 // DeleteClientProtocolMapper deletes a protocol mapper in client scope
-func (client *gocloak) DeleteClientProtocolMapper(token, realm, clientID, mapperID string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) DeleteClientProtocolMapper(ctx context.Context, token, realm, clientID, mapperID string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "DeleteClientProtocolMapper", realm, token).
 		Delete(client.getAdminRealmURL(realm, "clients", clientID, "protocol-mappers", "models", mapperID))
 
 	return checkForError(resp, err)
 }
 
 // GetKeyStoreConfig get keystoreconfig of the realm
-func (client *gocloak) GetKeyStoreConfig(token string, realm string) (*KeyStoreConfig, error) {
+func (client *gocloak) GetKeyStoreConfig(ctx context.Context, token string, realm string) (*KeyStoreConfig, error) {
 	var result KeyStoreConfig
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetKeyStoreConfig", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "keys"))
 
@@ -780,9 +1247,9 @@ func (client *gocloak) GetKeyStoreConfig(token string, realm string) (*KeyStoreC
 }
 
 // GetComponents get all components in realm
-func (client *gocloak) GetComponents(token string, realm string) ([]*Component, error) {
+func (client *gocloak) GetComponents(ctx context.Context, token string, realm string) ([]*Component, error) {
 	var result []*Component
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetComponents", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "components"))
 
@@ -793,9 +1260,9 @@ func (client *gocloak) GetComponents(token string, realm string) ([]*Component,
 	return result, nil
 }
 
-func (client *gocloak) getRoleMappings(token string, realm string, path string, objectID string) (*MappingsRepresentation, error) {
+func (client *gocloak) getRoleMappings(ctx context.Context, operation, token string, realm string, path string, objectID string) (*MappingsRepresentation, error) {
 	var result MappingsRepresentation
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, operation, realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, path, objectID, "role-mappings"))
 
@@ -807,19 +1274,19 @@ func (client *gocloak) getRoleMappings(token string, realm string, path string,
 }
 
 // GetRoleMappingByGroupID gets the role mappings by group
-func (client *gocloak) GetRoleMappingByGroupID(token string, realm string, groupID string) (*MappingsRepresentation, error) {
-	return client.getRoleMappings(token, realm, "groups", groupID)
+func (client *gocloak) GetRoleMappingByGroupID(ctx context.Context, token string, realm string, groupID string) (*MappingsRepresentation, error) {
+	return client.getRoleMappings(ctx, "GetRoleMappingByGroupID", token, realm, "groups", groupID)
 }
 
 // GetRoleMappingByUserID gets the role mappings by user
-func (client *gocloak) GetRoleMappingByUserID(token string, realm string, userID string) (*MappingsRepresentation, error) {
-	return client.getRoleMappings(token, realm, "users", userID)
+func (client *gocloak) GetRoleMappingByUserID(ctx context.Context, token string, realm string, userID string) (*MappingsRepresentation, error) {
+	return client.getRoleMappings(ctx, "GetRoleMappingByUserID", token, realm, "users", userID)
 }
 
 // GetGroup get group with id in realm
-func (client *gocloak) GetGroup(token string, realm string, groupID string) (*Group, error) {
+func (client *gocloak) GetGroup(ctx context.Context, token string, realm string, groupID string) (*Group, error) {
 	var result Group
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetGroup", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "groups", groupID))
 
@@ -831,14 +1298,14 @@ func (client *gocloak) GetGroup(token string, realm string, groupID string) (*Gr
 }
 
 // GetGroups get all groups in realm
-func (client *gocloak) GetGroups(token string, realm string, params GetGroupsParams) ([]*Group, error) {
+func (client *gocloak) GetGroups(ctx context.Context, token string, realm string, params GetGroupsParams) ([]*Group, error) {
 	var result []*Group
 	queryParams, err := GetQueryParams(params)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetGroups", realm, token).
 		SetResult(&result).
 		SetQueryParams(queryParams).
 		Get(client.getAdminRealmURL(realm, "groups"))
@@ -851,14 +1318,14 @@ func (client *gocloak) GetGroups(token string, realm string, params GetGroupsPar
 }
 
 // GetGroupMembers get a list of users of group with id in realm
-func (client *gocloak) GetGroupMembers(token string, realm string, groupID string, params GetGroupsParams) ([]*User, error) {
+func (client *gocloak) GetGroupMembers(ctx context.Context, token string, realm string, groupID string, params GetGroupsParams) ([]*User, error) {
 	var result []*User
 	queryParams, err := GetQueryParams(params)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetGroupMembers", realm, token).
 		SetResult(&result).
 		SetQueryParams(queryParams).
 		Get(client.getAdminRealmURL(realm, "groups", groupID, "members"))
@@ -871,10 +1338,16 @@ func (client *gocloak) GetGroupMembers(token string, realm string, groupID strin
 }
 
 // GetClientRoles get all roles for the given client in realm
-func (client *gocloak) GetClientRoles(token string, realm string, clientID string) ([]*Role, error) {
+func (client *gocloak) GetClientRoles(ctx context.Context, token string, realm string, clientID string, params ...GetRoleParams) ([]*Role, error) {
 	var result []*Role
-	resp, err := client.getRequestWithBearerAuth(token).
+	queryParams, err := getOptionalQueryParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetClientRoles", realm, token).
 		SetResult(&result).
+		SetQueryParams(queryParams).
 		Get(client.getAdminRealmURL(realm, "clients", clientID, "roles"))
 
 	if err := checkForError(resp, err); err != nil {
@@ -885,9 +1358,9 @@ func (client *gocloak) GetClientRoles(token string, realm string, clientID strin
 }
 
 // GetClientRole get a role for the given client in a realm by role name
-func (client *gocloak) GetClientRole(token string, realm string, clientID string, roleName string) (*Role, error) {
+func (client *gocloak) GetClientRole(ctx context.Context, token string, realm string, clientID string, roleName string) (*Role, error) {
 	var result Role
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetClientRole", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "clients", clientID, "roles", roleName))
 
@@ -899,13 +1372,13 @@ func (client *gocloak) GetClientRole(token string, realm string, clientID string
 }
 
 // GetClients gets all clients in realm
-func (client *gocloak) GetClients(token string, realm string, params GetClientsParams) ([]*Client, error) {
+func (client *gocloak) GetClients(ctx context.Context, token string, realm string, params GetClientsParams) ([]*Client, error) {
 	var result []*Client
 	queryParams, err := GetQueryParams(params)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetClients", realm, token).
 		SetResult(&result).
 		SetQueryParams(queryParams).
 		Get(client.getAdminRealmURL(realm, "clients"))
@@ -934,8 +1407,8 @@ func (client *gocloak) UserAttributeContains(attributes map[string][]string, att
 // -----------
 
 // CreateRealmRole creates a role in a realm
-func (client *gocloak) CreateRealmRole(token string, realm string, role Role) (string, error) {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) CreateRealmRole(ctx context.Context, token string, realm string, role Role) (string, error) {
+	resp, err := client.getRequestWithBearerAuth(ctx, "CreateRealmRole", realm, token).
 		SetBody(role).
 		Post(client.getAdminRealmURL(realm, "roles"))
 
@@ -946,9 +1419,9 @@ func (client *gocloak) CreateRealmRole(token string, realm string, role Role) (s
 }
 
 // GetRealmRole returns a role from a realm by role's name
-func (client *gocloak) GetRealmRole(token string, realm string, roleName string) (*Role, error) {
+func (client *gocloak) GetRealmRole(ctx context.Context, token string, realm string, roleName string) (*Role, error) {
 	var result Role
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetRealmRole", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "roles", roleName))
 
@@ -960,10 +1433,16 @@ func (client *gocloak) GetRealmRole(token string, realm string, roleName string)
 }
 
 // GetRealmRoles get all roles of the given realm.
-func (client *gocloak) GetRealmRoles(token string, realm string) ([]*Role, error) {
+func (client *gocloak) GetRealmRoles(ctx context.Context, token string, realm string, params ...GetRoleParams) ([]*Role, error) {
 	var result []*Role
-	resp, err := client.getRequestWithBearerAuth(token).
+	queryParams, err := getOptionalQueryParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetRealmRoles", realm, token).
 		SetResult(&result).
+		SetQueryParams(queryParams).
 		Get(client.getAdminRealmURL(realm, "roles"))
 
 	if err := checkForError(resp, err); err != nil {
@@ -974,9 +1453,9 @@ func (client *gocloak) GetRealmRoles(token string, realm string) ([]*Role, error
 }
 
 // GetRealmRolesByUserID returns all roles assigned to the given user
-func (client *gocloak) GetRealmRolesByUserID(token string, realm string, userID string) ([]*Role, error) {
+func (client *gocloak) GetRealmRolesByUserID(ctx context.Context, token string, realm string, userID string) ([]*Role, error) {
 	var result []*Role
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetRealmRolesByUserID", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "users", userID, "role-mappings", "realm"))
 
@@ -988,9 +1467,9 @@ func (client *gocloak) GetRealmRolesByUserID(token string, realm string, userID
 }
 
 // GetRealmRolesByGroupID returns all roles assigned to the given group
-func (client *gocloak) GetRealmRolesByGroupID(token string, realm string, groupID string) ([]*Role, error) {
+func (client *gocloak) GetRealmRolesByGroupID(ctx context.Context, token string, realm string, groupID string) ([]*Role, error) {
 	var result []*Role
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetRealmRolesByGroupID", realm, token).
 		Get(client.getAdminRealmURL(realm, "groups", groupID, "role-mappings", "realm"))
 
 	if err = checkForError(resp, err); err != nil {
@@ -1001,8 +1480,8 @@ func (client *gocloak) GetRealmRolesByGroupID(token string, realm string, groupI
 }
 
 // UpdateRealmRole updates a role in a realm
-func (client *gocloak) UpdateRealmRole(token string, realm string, roleName string, role Role) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) UpdateRealmRole(ctx context.Context, token string, realm string, roleName string, role Role) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "UpdateRealmRole", realm, token).
 		SetBody(role).
 		Put(client.getAdminRealmURL(realm, "roles", roleName))
 
@@ -1010,16 +1489,16 @@ func (client *gocloak) UpdateRealmRole(token string, realm string, roleName stri
 }
 
 // DeleteRealmRole deletes a role in a realm by role's name
-func (client *gocloak) DeleteRealmRole(token string, realm string, roleName string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) DeleteRealmRole(ctx context.Context, token string, realm string, roleName string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "DeleteRealmRole", realm, token).
 		Delete(client.getAdminRealmURL(realm, "roles", roleName))
 
 	return checkForError(resp, err)
 }
 
 // AddRealmRoleToUser adds realm-level role mappings
-func (client *gocloak) AddRealmRoleToUser(token string, realm string, userID string, roles []Role) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) AddRealmRoleToUser(ctx context.Context, token string, realm string, userID string, roles []Role) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "AddRealmRoleToUser", realm, token).
 		SetBody(roles).
 		Post(client.getAdminRealmURL(realm, "users", userID, "role-mappings", "realm"))
 
@@ -1027,24 +1506,24 @@ func (client *gocloak) AddRealmRoleToUser(token string, realm string, userID str
 }
 
 // DeleteRealmRoleFromUser deletes realm-level role mappings
-func (client *gocloak) DeleteRealmRoleFromUser(token string, realm string, userID string, roles []Role) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) DeleteRealmRoleFromUser(ctx context.Context, token string, realm string, userID string, roles []Role) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "DeleteRealmRoleFromUser", realm, token).
 		SetBody(roles).
 		Delete(client.getAdminRealmURL(realm, "users", userID, "role-mappings", "realm"))
 
 	return checkForError(resp, err)
 }
 
-func (client *gocloak) AddRealmRoleComposite(token string, realm string, roleName string, roles []Role) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) AddRealmRoleComposite(ctx context.Context, token string, realm string, roleName string, roles []Role) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "AddRealmRoleComposite", realm, token).
 		SetBody(roles).
 		Post(client.getAdminRealmURL(realm, "roles", roleName, "composites"))
 
 	return checkForError(resp, err)
 }
 
-func (client *gocloak) DeleteRealmRoleComposite(token string, realm string, roleName string, roles []Role) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) DeleteRealmRoleComposite(ctx context.Context, token string, realm string, roleName string, roles []Role) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "DeleteRealmRoleComposite", realm, token).
 		SetBody(roles).
 		Delete(client.getAdminRealmURL(realm, "roles", roleName, "composites"))
 
@@ -1056,9 +1535,9 @@ func (client *gocloak) DeleteRealmRoleComposite(token string, realm string, role
 // -----
 
 // GetRealm returns top-level representation of the realm
-func (client *gocloak) GetRealm(token string, realm string) (*RealmRepresentation, error) {
+func (client *gocloak) GetRealm(ctx context.Context, token string, realm string) (*RealmRepresentation, error) {
 	var result RealmRepresentation
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetRealm", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm))
 
@@ -1070,11 +1549,11 @@ func (client *gocloak) GetRealm(token string, realm string) (*RealmRepresentatio
 }
 
 // GetRealms returns top-level representation of all realms
-func (client *gocloak) GetRealms(token string) ([]*RealmRepresentation, error) {
+func (client *gocloak) GetRealms(ctx context.Context, token string) ([]*RealmRepresentation, error) {
 	var result []*RealmRepresentation
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := withBearerAuth(client.newRequest(ctx, "GetRealms", ""), token).
 		SetResult(&result).
-		Get(client.getAdminRealmURL(""))
+		Get(client.getAdminRealmsURL())
 
 	if err = checkForError(resp, err); err != nil {
 		return nil, err
@@ -1084,10 +1563,10 @@ func (client *gocloak) GetRealms(token string) ([]*RealmRepresentation, error) {
 }
 
 // CreateRealm creates a realm
-func (client *gocloak) CreateRealm(token string, realm RealmRepresentation) (string, error) {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) CreateRealm(ctx context.Context, token string, realm RealmRepresentation) (string, error) {
+	resp, err := client.getRequestWithBearerAuth(ctx, "CreateRealm", PString(realm.Realm), token).
 		SetBody(&realm).
-		Post(client.getAdminRealmURL(""))
+		Post(client.getAdminRealmsURL())
 
 	if err := checkForError(resp, err); err != nil {
 		return "", err
@@ -1096,15 +1575,15 @@ func (client *gocloak) CreateRealm(token string, realm RealmRepresentation) (str
 }
 
 // DeleteRealm removes a realm
-func (client *gocloak) DeleteRealm(token string, realm string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) DeleteRealm(ctx context.Context, token string, realm string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "DeleteRealm", realm, token).
 		Delete(client.getAdminRealmURL(realm))
 	return checkForError(resp, err)
 }
 
 // ClearRealmCache clears realm cache
-func (client *gocloak) ClearRealmCache(token string, realm string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) ClearRealmCache(ctx context.Context, token string, realm string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "ClearRealmCache", realm, token).
 		Post(client.getAdminRealmURL(realm, "clear-realm-cache"))
 	return checkForError(resp, err)
 }
@@ -1114,8 +1593,8 @@ func (client *gocloak) ClearRealmCache(token string, realm string) error {
 // -----
 
 // CreateUser creates the given user in the given realm and returns it's userID
-func (client *gocloak) CreateUser(token string, realm string, user User) (string, error) {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) CreateUser(ctx context.Context, token string, realm string, user User) (string, error) {
+	resp, err := client.getRequestWithBearerAuth(ctx, "CreateUser", realm, token).
 		SetBody(user).
 		Post(client.getAdminRealmURL(realm, "users"))
 
@@ -1126,21 +1605,21 @@ func (client *gocloak) CreateUser(token string, realm string, user User) (string
 }
 
 // DeleteUser delete a given user
-func (client *gocloak) DeleteUser(token string, realm string, userID string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) DeleteUser(ctx context.Context, token string, realm string, userID string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "DeleteUser", realm, token).
 		Delete(client.getAdminRealmURL(realm, "users", userID))
 
 	return checkForError(resp, err)
 }
 
 // GetUserByID fetches a user from the given realm with the given userID
-func (client *gocloak) GetUserByID(accessToken string, realm string, userID string) (*User, error) {
+func (client *gocloak) GetUserByID(ctx context.Context, accessToken string, realm string, userID string) (*User, error) {
 	if userID == "" {
 		return nil, errors.New("userID shall not be empty")
 	}
 
 	var result User
-	resp, err := client.getRequestWithBearerAuth(accessToken).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetUserByID", realm, accessToken).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "users", userID))
 
@@ -1152,9 +1631,9 @@ func (client *gocloak) GetUserByID(accessToken string, realm string, userID stri
 }
 
 // GetUserCount gets the user count in the realm
-func (client *gocloak) GetUserCount(token string, realm string) (int, error) {
+func (client *gocloak) GetUserCount(ctx context.Context, token string, realm string) (int, error) {
 	var result int
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetUserCount", realm, token).
 		SetResult(&result).
 		Get(client.getAdminRealmURL(realm, "users", "count"))
 
@@ -1166,10 +1645,16 @@ func (client *gocloak) GetUserCount(token string, realm string) (int, error) {
 }
 
 // GetUserGroups get all groups for user
-func (client *gocloak) GetUserGroups(token string, realm string, userID string) ([]*UserGroup, error) {
+func (client *gocloak) GetUserGroups(ctx context.Context, token string, realm string, userID string, params ...GetUserGroupsParams) ([]*UserGroup, error) {
 	var result []*UserGroup
-	resp, err := client.getRequestWithBearerAuth(token).
+	queryParams, err := getOptionalQueryParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetUserGroups", realm, token).
 		SetResult(&result).
+		SetQueryParams(queryParams).
 		Get(client.getAdminRealmURL(realm, "users", userID, "groups"))
 
 	if err := checkForError(resp, err); err != nil {
@@ -1180,14 +1665,14 @@ func (client *gocloak) GetUserGroups(token string, realm string, userID string)
 }
 
 // GetUsers get all users in realm
-func (client *gocloak) GetUsers(token string, realm string, params GetUsersParams) ([]*User, error) {
+func (client *gocloak) GetUsers(ctx context.Context, token string, realm string, params GetUsersParams) ([]*User, error) {
 	var result []*User
 	queryParams, err := GetQueryParams(params)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetUsers", realm, token).
 		SetResult(&result).
 		SetQueryParams(queryParams).
 		Get(client.getAdminRealmURL(realm, "users"))
@@ -1200,10 +1685,16 @@ func (client *gocloak) GetUsers(token string, realm string, params GetUsersParam
 }
 
 // GetUsersByRoleName returns all users have a given role
-func (client *gocloak) GetUsersByRoleName(token string, realm string, roleName string) ([]*User, error) {
+func (client *gocloak) GetUsersByRoleName(ctx context.Context, token string, realm string, roleName string, params ...GetUsersByRoleParams) ([]*User, error) {
 	var result []*User
-	resp, err := client.getRequestWithBearerAuth(token).
+	queryParams, err := getOptionalQueryParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetUsersByRoleName", realm, token).
 		SetResult(&result).
+		SetQueryParams(queryParams).
 		Get(client.getAdminRealmURL(realm, "roles", roleName, "users"))
 
 	if err := checkForError(resp, err); err != nil {
@@ -1214,9 +1705,9 @@ func (client *gocloak) GetUsersByRoleName(token string, realm string, roleName s
 }
 
 // SetPassword sets a new password for the user with the given id. Needs elevated privileges
-func (client *gocloak) SetPassword(token string, userID string, realm string, password string, temporary bool) error {
+func (client *gocloak) SetPassword(ctx context.Context, token string, userID string, realm string, password string, temporary bool) error {
 	requestBody := SetPasswordRequest{Password: &password, Temporary: &temporary, Type: StringP("password")}
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "SetPassword", realm, token).
 		SetBody(requestBody).
 		Put(client.getAdminRealmURL(realm, "users", userID, "reset-password"))
 
@@ -1224,8 +1715,8 @@ func (client *gocloak) SetPassword(token string, userID string, realm string, pa
 }
 
 // UpdateUser updates a given user
-func (client *gocloak) UpdateUser(token string, realm string, user User) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) UpdateUser(ctx context.Context, token string, realm string, user User) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "UpdateUser", realm, token).
 		SetBody(user).
 		Put(client.getAdminRealmURL(realm, "users", PString(user.ID)))
 
@@ -1233,25 +1724,25 @@ func (client *gocloak) UpdateUser(token string, realm string, user User) error {
 }
 
 // AddUserToGroup puts given user to given group
-func (client *gocloak) AddUserToGroup(token string, realm string, userID string, groupID string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) AddUserToGroup(ctx context.Context, token string, realm string, userID string, groupID string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "AddUserToGroup", realm, token).
 		Put(client.getAdminRealmURL(realm, "users", userID, "groups", groupID))
 
 	return checkForError(resp, err)
 }
 
 // DeleteUserFromGroup deletes given user from given group
-func (client *gocloak) DeleteUserFromGroup(token string, realm string, userID string, groupID string) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) DeleteUserFromGroup(ctx context.Context, token string, realm string, userID string, groupID string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "DeleteUserFromGroup", realm, token).
 		Delete(client.getAdminRealmURL(realm, "users", userID, "groups", groupID))
 
 	return checkForError(resp, err)
 }
 
 // GetUserSessions returns user sessions associated with the user
-func (client *gocloak) GetUserSessions(token, realm, userID string) ([]*UserSessionRepresentation, error) {
+func (client *gocloak) GetUserSessions(ctx context.Context, token, realm, userID string) ([]*UserSessionRepresentation, error) {
 	var res []*UserSessionRepresentation
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetUserSessions", realm, token).
 		SetResult(&res).
 		Get(client.getAdminRealmURL(realm, "users", userID, "sessions"))
 
@@ -1261,10 +1752,65 @@ func (client *gocloak) GetUserSessions(token, realm, userID string) ([]*UserSess
 	return res, nil
 }
 
+// LogoutAllSessions logs out all sessions of the user
+func (client *gocloak) LogoutAllSessions(ctx context.Context, token, realm, userID string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "LogoutAllSessions", realm, token).
+		Post(client.getAdminRealmURL(realm, "users", userID, "logout"))
+
+	return checkForError(resp, err)
+}
+
+// LogoutUserSession logs out a single session by its ID
+func (client *gocloak) LogoutUserSession(ctx context.Context, token, realm, session string) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "LogoutUserSession", realm, token).
+		Delete(client.getAdminRealmURL(realm, "sessions", session))
+
+	return checkForError(resp, err)
+}
+
+// LogoutAllRealmSessions logs out all sessions of the realm and notifies the clients with an admin URL
+func (client *gocloak) LogoutAllRealmSessions(ctx context.Context, token, realm string) (*GlobalRequestResult, error) {
+	var result GlobalRequestResult
+	resp, err := client.getRequestWithBearerAuth(ctx, "LogoutAllRealmSessions", realm, token).
+		SetResult(&result).
+		Post(client.getAdminRealmURL(realm, "logout-all"))
+
+	if err := checkForError(resp, err); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PushRealmRevocation pushes the not-before revocation policy of the realm to the clients with an admin URL
+func (client *gocloak) PushRealmRevocation(ctx context.Context, token, realm string) (*GlobalRequestResult, error) {
+	var result GlobalRequestResult
+	resp, err := client.getRequestWithBearerAuth(ctx, "PushRealmRevocation", realm, token).
+		SetResult(&result).
+		Post(client.getAdminRealmURL(realm, "push-revocation"))
+
+	if err := checkForError(resp, err); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PushClientRevocation pushes the not-before revocation policy of the client to its admin URL
+func (client *gocloak) PushClientRevocation(ctx context.Context, token, realm, clientID string) (*GlobalRequestResult, error) {
+	var result GlobalRequestResult
+	resp, err := client.getRequestWithBearerAuth(ctx, "PushClientRevocation", realm, token).
+		SetResult(&result).
+		Post(client.getAdminRealmURL(realm, "clients", clientID, "push-revocation"))
+
+	if err := checkForError(resp, err); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 // GetUserOfflineSessionsForClient returns offline sessions associated with the user and client
-func (client *gocloak) GetUserOfflineSessionsForClient(token, realm, userID, clientID string) ([]*UserSessionRepresentation, error) {
+func (client *gocloak) GetUserOfflineSessionsForClient(ctx context.Context, token, realm, userID, clientID string) ([]*UserSessionRepresentation, error) {
 	var res []*UserSessionRepresentation
-	resp, err := client.getRequestWithBearerAuth(token).
+	resp, err := client.getRequestWithBearerAuth(ctx, "GetUserOfflineSessionsForClient", realm, token).
 		SetResult(&res).
 		Get(client.getAdminRealmURL(realm, "users", userID, "offline-sessions", clientID))
 
@@ -1275,8 +1821,8 @@ func (client *gocloak) GetUserOfflineSessionsForClient(token, realm, userID, cli
 }
 
 // AddClientRoleToUser adds client-level role mappings
-func (client *gocloak) AddClientRoleToUser(token string, realm string, clientID string, userID string, roles []Role) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) AddClientRoleToUser(ctx context.Context, token string, realm string, clientID string, userID string, roles []Role) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "AddClientRoleToUser", realm, token).
 		SetBody(roles).
 		Post(client.getAdminRealmURL(realm, "users", userID, "role-mappings", "clients", clientID))
 
@@ -1284,8 +1830,8 @@ func (client *gocloak) AddClientRoleToUser(token string, realm string, clientID
 }
 
 // AddClientRoleToGroup adds client-level role mapping
-func (client *gocloak) AddClientRoleToGroup(token string, realm string, clientID string, groupID string, roles []Role) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) AddClientRoleToGroup(ctx context.Context, token string, realm string, clientID string, groupID string, roles []Role) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "AddClientRoleToGroup", realm, token).
 		SetBody(roles).
 		Post(client.getAdminRealmURL(realm, "groups", groupID, "role-mappings", "clients", clientID))
 
@@ -1293,8 +1839,8 @@ func (client *gocloak) AddClientRoleToGroup(token string, realm string, clientID
 }
 
 // DeleteClientRoleFromUser adds client-level role mappings
-func (client *gocloak) DeleteClientRoleFromUser(token string, realm string, clientID string, userID string, roles []Role) error {
-	resp, err := client.getRequestWithBearerAuth(token).
+func (client *gocloak) DeleteClientRoleFromUser(ctx context.Context, token string, realm string, clientID string, userID string, roles []Role) error {
+	resp, err := client.getRequestWithBearerAuth(ctx, "DeleteClientRoleFromUser", realm, token).
 		SetBody(roles).
 		Delete(client.getAdminRealmURL(realm, "users", userID, "role-mappings", "clients", clientID))
 
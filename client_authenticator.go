@@ -0,0 +1,188 @@
+package gocloak
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-resty/resty/v2"
+)
+
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// ClientAuthenticator authenticates a confidential client at the token, introspection and logout endpoints
+type ClientAuthenticator interface {
+	// Authenticate adds the credentials of the client to the request.
+	// The audience is the issuer URL of the realm, it is empty for the client secret authenticators
+	Authenticate(req *resty.Request, clientID, audience string) error
+}
+
+// needsAudience reports whether the authenticator uses the audience, so the issuer URL,
+// which may need a discovery request, is only resolved for the signed JWT and custom authenticators
+func needsAudience(authenticator ClientAuthenticator) bool {
+	switch authenticator.(type) {
+	case *clientSecretBasic, *clientSecretPost:
+		return false
+	}
+	return true
+}
+
+type clientSecretBasic struct {
+	secret string
+}
+
+// NewClientSecretBasicAuthenticator creates a ClientAuthenticator for the client_secret_basic method,
+// which is used by default if a client secret is given
+func NewClientSecretBasicAuthenticator(secret string) ClientAuthenticator {
+	return &clientSecretBasic{secret: secret}
+}
+
+func (a *clientSecretBasic) Authenticate(req *resty.Request, clientID, audience string) error {
+	httpBasicAuth := base64.URLEncoding.EncodeToString([]byte(clientID + ":" + a.secret))
+	req.SetHeader("Authorization", "Basic "+httpBasicAuth)
+	return nil
+}
+
+type clientSecretPost struct {
+	secret string
+}
+
+// NewClientSecretPostAuthenticator creates a ClientAuthenticator for the client_secret_post method
+func NewClientSecretPostAuthenticator(secret string) ClientAuthenticator {
+	return &clientSecretPost{secret: secret}
+}
+
+func (a *clientSecretPost) Authenticate(req *resty.Request, clientID, audience string) error {
+	req.SetFormData(map[string]string{
+		"client_id":     clientID,
+		"client_secret": a.secret,
+	})
+	return nil
+}
+
+// JWTAuthenticatorOption configures the client assertions of a signed JWT ClientAuthenticator
+type JWTAuthenticatorOption func(*jwtAuthenticator)
+
+// WithAssertionKeyID sets the kid header of the client assertions
+func WithAssertionKeyID(keyID string) JWTAuthenticatorOption {
+	return func(a *jwtAuthenticator) {
+		a.keyID = keyID
+	}
+}
+
+// WithAssertionLifetime sets how long the client assertions are valid, one minute by default
+func WithAssertionLifetime(lifetime time.Duration) JWTAuthenticatorOption {
+	return func(a *jwtAuthenticator) {
+		a.lifetime = lifetime
+	}
+}
+
+// WithAssertionJTI sets the generator of the jti claim, a random string by default
+func WithAssertionJTI(generate func() (string, error)) JWTAuthenticatorOption {
+	return func(a *jwtAuthenticator) {
+		a.jti = generate
+	}
+}
+
+// WithAssertionSigningMethod sets the algorithm of the client assertions, e.g. jwt.SigningMethodPS256
+func WithAssertionSigningMethod(method jwt.SigningMethod) JWTAuthenticatorOption {
+	return func(a *jwtAuthenticator) {
+		a.method = method
+	}
+}
+
+type jwtAuthenticator struct {
+	key      interface{}
+	method   jwt.SigningMethod
+	keyID    string
+	lifetime time.Duration
+	jti      func() (string, error)
+	now      func() time.Time
+}
+
+// NewPrivateKeyJWTAuthenticator creates a ClientAuthenticator for the private_key_jwt method.
+// The client assertions are signed with the RSA (RS256) or EC (ES256, ES384, ES512) private key
+func NewPrivateKeyJWTAuthenticator(key crypto.PrivateKey, options ...JWTAuthenticatorOption) (ClientAuthenticator, error) {
+	var method jwt.SigningMethod
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		switch key.Curve.Params().BitSize {
+		case 256:
+			method = jwt.SigningMethodES256
+		case 384:
+			method = jwt.SigningMethodES384
+		case 521:
+			method = jwt.SigningMethodES512
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", key.Curve.Params().Name)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported private key type: %T", key)
+	}
+	return newJWTAuthenticator(key, method, options), nil
+}
+
+// NewClientSecretJWTAuthenticator creates a ClientAuthenticator for the client_secret_jwt method.
+// The client assertions are signed with the client secret (HS256)
+func NewClientSecretJWTAuthenticator(secret []byte, options ...JWTAuthenticatorOption) (ClientAuthenticator, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("the client secret must not be empty")
+	}
+	return newJWTAuthenticator(secret, jwt.SigningMethodHS256, options), nil
+}
+
+func newJWTAuthenticator(key interface{}, method jwt.SigningMethod, options []JWTAuthenticatorOption) *jwtAuthenticator {
+	a := &jwtAuthenticator{
+		key:      key,
+		method:   method,
+		lifetime: time.Minute,
+		jti: func() (string, error) {
+			return randomString(16)
+		},
+		now: time.Now,
+	}
+	for _, option := range options {
+		option(a)
+	}
+	return a
+}
+
+func (a *jwtAuthenticator) Authenticate(req *resty.Request, clientID, audience string) error {
+	assertion, err := a.assertion(clientID, audience)
+	if err != nil {
+		return err
+	}
+	req.SetFormData(map[string]string{
+		"client_id":             clientID,
+		"client_assertion_type": clientAssertionType,
+		"client_assertion":      assertion,
+	})
+	return nil
+}
+
+func (a *jwtAuthenticator) assertion(clientID, audience string) (string, error) {
+	jti, err := a.jti()
+	if err != nil {
+		return "", err
+	}
+	now := a.now()
+	token := jwt.NewWithClaims(a.method, jwt.StandardClaims{
+		Issuer:    clientID,
+		Subject:   clientID,
+		Audience:  audience,
+		Id:        jti,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(a.lifetime).Unix(),
+	})
+	if len(a.keyID) > 0 {
+		token.Header["kid"] = a.keyID
+	}
+	return token.SignedString(a.key)
+}
@@ -0,0 +1,160 @@
+package gocloak
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func formServer(t *testing.T, forms chan<- url.Values) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		form := r.PostForm
+		if user, password, ok := r.BasicAuth(); ok {
+			form.Set("basic_user", user)
+			form.Set("basic_password", password)
+		}
+		forms <- form
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(JWT{AccessToken: "access"})
+	}))
+}
+
+func TestNewPrivateKeyJWTAuthenticator(t *testing.T) {
+	t.Parallel()
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	authenticator, err := NewPrivateKeyJWTAuthenticator(key,
+		WithAssertionKeyID("kid"),
+		WithAssertionLifetime(30*time.Second),
+		WithAssertionJTI(func() (string, error) { return "jti", nil }),
+	)
+	assert.NoError(t, err)
+
+	assertion, err := authenticator.(*jwtAuthenticator).assertion("my-client", "http://localhost/auth/realms/realm")
+	assert.NoError(t, err)
+
+	claims := &jwt.StandardClaims{}
+	token, err := jwt.ParseWithClaims(assertion, claims, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ES384", token.Method.Alg())
+	assert.Equal(t, "kid", token.Header["kid"])
+	assert.Equal(t, "my-client", claims.Issuer)
+	assert.Equal(t, "my-client", claims.Subject)
+	assert.Equal(t, "http://localhost/auth/realms/realm", claims.Audience)
+	assert.Equal(t, "jti", claims.Id)
+	assert.EqualValues(t, 30, claims.ExpiresAt-claims.IssuedAt)
+
+	_, err = NewPrivateKeyJWTAuthenticator("not a key")
+	assert.Error(t, err)
+}
+
+func TestGocloak_GetTokenWithPrivateKeyJWT(t *testing.T) {
+	t.Parallel()
+	forms := make(chan url.Values, 1)
+	server := formServer(t, forms)
+	defer server.Close()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	authenticator, err := NewPrivateKeyJWTAuthenticator(key)
+	assert.NoError(t, err)
+
+	client := NewClient(server.URL)
+	_, err = client.GetToken(context.Background(), "realm", TokenOptions{
+		ClientID:            StringP("my-client"),
+		GrantType:           StringP("client_credentials"),
+		ClientAuthenticator: authenticator,
+	})
+	assert.NoError(t, err)
+
+	form := <-forms
+	assert.Equal(t, "my-client", form.Get("client_id"))
+	assert.Equal(t, clientAssertionType, form.Get("client_assertion_type"))
+	assert.Empty(t, form.Get("basic_user"))
+	claims := &jwt.StandardClaims{}
+	_, err = jwt.ParseWithClaims(form.Get("client_assertion"), claims, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/auth/realms/realm", claims.Audience)
+}
+
+func TestGocloak_SetClientAuthenticator(t *testing.T) {
+	t.Parallel()
+	forms := make(chan url.Values, 1)
+	server := formServer(t, forms)
+	defer server.Close()
+	authenticator, err := NewClientSecretJWTAuthenticator([]byte("secret"))
+	assert.NoError(t, err)
+
+	client := NewClient(server.URL)
+	client.SetClientAuthenticator("realm", "my-client", authenticator)
+
+	_, err = client.RetrospectToken(context.Background(), "token", "my-client", "", "realm")
+	assert.NoError(t, err)
+	form := <-forms
+	assert.Equal(t, clientAssertionType, form.Get("client_assertion_type"))
+	token, err := jwt.Parse(form.Get("client_assertion"), func(token *jwt.Token) (interface{}, error) {
+		return []byte("secret"), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "HS256", token.Method.Alg())
+
+	// other clients still authenticate with their secret
+	assert.NoError(t, client.Logout(context.Background(), "other-client", "secret", "realm", "refresh"))
+	form = <-forms
+	assert.Empty(t, form.Get("client_assertion"))
+	assert.Equal(t, "other-client", form.Get("basic_user"))
+
+	client.SetClientAuthenticator("realm", "post-client", NewClientSecretPostAuthenticator("secret"))
+	assert.NoError(t, client.Logout(context.Background(), "post-client", "", "realm", "refresh"))
+	form = <-forms
+	assert.Equal(t, "secret", form.Get("client_secret"))
+	assert.Empty(t, form.Get("basic_user"))
+}
+
+type audienceAuthenticator struct {
+	audiences chan string
+}
+
+func (a *audienceAuthenticator) Authenticate(req *resty.Request, clientID, audience string) error {
+	a.audiences <- audience
+	return nil
+}
+
+func TestNeedsAudience(t *testing.T) {
+	t.Parallel()
+	authenticator, err := NewClientSecretJWTAuthenticator([]byte("secret"))
+	assert.NoError(t, err)
+	assert.True(t, needsAudience(authenticator))
+	assert.True(t, needsAudience(&audienceAuthenticator{}))
+	assert.False(t, needsAudience(NewClientSecretBasicAuthenticator("secret")))
+	assert.False(t, needsAudience(NewClientSecretPostAuthenticator("secret")))
+
+	forms := make(chan url.Values, 1)
+	server := formServer(t, forms)
+	defer server.Close()
+	custom := &audienceAuthenticator{audiences: make(chan string, 1)}
+	_, err = NewClient(server.URL).GetToken(context.Background(), "realm", TokenOptions{
+		ClientID:            StringP("my-client"),
+		GrantType:           StringP("client_credentials"),
+		ClientAuthenticator: custom,
+	})
+	assert.NoError(t, err)
+	<-forms
+	assert.Equal(t, server.URL+"/auth/realms/realm", <-custom.audiences)
+}
@@ -0,0 +1,198 @@
+package gocloak
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Logger receives the log messages of the HTTP client
+type Logger interface {
+	Errorf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Debugf(format string, v ...interface{})
+}
+
+// RetryPolicy configures how often and how long apart failed requests are retried.
+// Requests are retried after connection errors and the retry status codes.
+// Only idempotent requests are retried unless RetryPOST is set
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt, no retries if zero
+	MaxRetries int
+	// WaitTime is the wait time before the first retry, which doubles for each further retry
+	WaitTime time.Duration
+	// MaxWaitTime limits the wait time between two retries, also if keycloak asks for a longer one with Retry-After
+	MaxWaitTime time.Duration
+	// Jitter is the part of the wait time which is randomized, between 0 and 1
+	Jitter float64
+	// RetryStatusCodes are the status codes which are retried, 429, 502, 503 and 504 by default
+	RetryStatusCodes []int
+	// RetryPOST enables retrying POST requests, which may create objects twice
+	RetryPOST bool
+	// OnRetry is called before each retry
+	OnRetry func(info RetryInfo)
+}
+
+// SetTimeout sets the timeout of a request including connecting, redirects and reading the response
+func SetTimeout(timeout time.Duration) ClientOption {
+	return func(client *gocloak) {
+		client.timeout = timeout
+	}
+}
+
+// SetConnectTimeout sets the timeout of establishing the connection and the TLS handshake
+func SetConnectTimeout(timeout time.Duration) ClientOption {
+	return func(client *gocloak) {
+		client.connectTimeout = timeout
+	}
+}
+
+// SetTLSConfig sets the TLS configuration of the connections to keycloak
+func SetTLSConfig(config *tls.Config) ClientOption {
+	return func(client *gocloak) {
+		client.tlsConfig = config
+	}
+}
+
+// SetCABundle adds the PEM encoded certificates to the certificate authorities keycloak is verified with.
+// The certificates are trusted in addition to the system ones, blocks which are no certificates are ignored.
+// If a bundle contains no certificate, or the RootCAs of SetTLSConfig are set as well, the requests of the client fail.
+// As the options cannot return an error, it is logged to the logger of SetLogger when the client is created
+// and returned by every request
+func SetCABundle(pemCerts []byte) ClientOption {
+	return func(client *gocloak) {
+		client.caBundles = append(client.caBundles, pemCerts)
+	}
+}
+
+// SetProxy sets the URL of the proxy the requests are sent through,
+// by default the proxy is taken from the environment
+func SetProxy(proxyURL string) ClientOption {
+	return func(client *gocloak) {
+		client.proxyURL = proxyURL
+	}
+}
+
+// SetUserAgent sets the User-Agent header of the requests
+func SetUserAgent(userAgent string) ClientOption {
+	return func(client *gocloak) {
+		client.userAgent = userAgent
+	}
+}
+
+// SetDefaultRealm sets the realm used by the requests which are called with an empty realm
+func SetDefaultRealm(realm string) ClientOption {
+	return func(client *gocloak) {
+		client.defaultRealm = realm
+	}
+}
+
+// SetRetryPolicy sets how failed requests are retried
+func SetRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(client *gocloak) {
+		client.retryPolicy = &policy
+	}
+}
+
+// SetLogger sets the logger of the HTTP client
+func SetLogger(logger Logger) ClientOption {
+	return func(client *gocloak) {
+		client.logger = logger
+	}
+}
+
+// SetDebug enables logging the requests and responses
+func SetDebug(debug bool) ClientOption {
+	return func(client *gocloak) {
+		client.debug = debug
+	}
+}
+
+// applyHTTPOptions sets the timeouts, the proxy, the user agent and the logging of the resty client
+func (client *gocloak) applyHTTPOptions() {
+	if client.timeout > 0 {
+		client.restyClient.SetTimeout(client.timeout)
+	}
+	if client.connectTimeout > 0 {
+		if transport, ok := client.restyClient.GetClient().Transport.(*http.Transport); ok {
+			transport.DialContext = (&net.Dialer{
+				Timeout:   client.connectTimeout,
+				KeepAlive: 30 * time.Second,
+			}).DialContext
+			transport.TLSHandshakeTimeout = client.connectTimeout
+		}
+	}
+	if len(client.proxyURL) > 0 {
+		client.restyClient.SetProxy(client.proxyURL)
+	}
+	if len(client.userAgent) > 0 {
+		client.restyClient.SetHeader("User-Agent", client.userAgent)
+	}
+	if client.logger != nil {
+		client.restyClient.SetLogger(client.logger)
+	}
+	if client.debug {
+		client.restyClient.SetDebug(true)
+	}
+}
+
+// applyTLSConfig sets the TLS configuration and the CA bundles after all options are applied,
+// so they do not depend on the order of the options
+func (client *gocloak) applyTLSConfig() {
+	if client.tlsConfig == nil && len(client.caBundles) == 0 {
+		return
+	}
+
+	config := &tls.Config{}
+	if client.tlsConfig != nil {
+		config = client.tlsConfig.Clone()
+	}
+	if len(client.caBundles) > 0 {
+		pool, err := client.rootCAs(config.RootCAs)
+		if err != nil {
+			// the options cannot return an error, so it is logged right away and returned by every request
+			if client.logger != nil {
+				client.logger.Errorf("invalid TLS configuration, every request fails: %v", err)
+			}
+			client.restyClient.OnBeforeRequest(func(*resty.Client, *resty.Request) error {
+				return err
+			})
+			return
+		}
+		config.RootCAs = pool
+	}
+	client.restyClient.SetTLSClientConfig(config)
+}
+
+// rootCAs returns a new pool of the system certificates and the CA bundles.
+// The pool of the TLS config is never changed, as it belongs to the caller
+func (client *gocloak) rootCAs(configured *x509.CertPool) (*x509.CertPool, error) {
+	if configured != nil {
+		return nil, errors.New("the CA bundles cannot be combined with the RootCAs of the TLS config, add them to the RootCAs instead")
+	}
+	// the system pool is a copy, which may be changed
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	for i, pemCerts := range client.caBundles {
+		if !pool.AppendCertsFromPEM(pemCerts) {
+			return nil, fmt.Errorf("the CA bundle %d contains no certificate", i+1)
+		}
+	}
+	return pool, nil
+}
+
+// getRealm returns the realm or the default realm if the realm is empty
+func (client *gocloak) getRealm(realm string) string {
+	if len(realm) == 0 {
+		return client.defaultRealm
+	}
+	return realm
+}
@@ -0,0 +1,207 @@
+package gocloak
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testLogger struct {
+	messages int32
+}
+
+func (l *testLogger) Errorf(format string, v ...interface{}) { atomic.AddInt32(&l.messages, 1) }
+func (l *testLogger) Warnf(format string, v ...interface{})  { atomic.AddInt32(&l.messages, 1) }
+func (l *testLogger) Debugf(format string, v ...interface{}) { atomic.AddInt32(&l.messages, 1) }
+
+func TestNewClient_Options(t *testing.T) {
+	t.Parallel()
+	requests := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	logger := &testLogger{}
+
+	client := NewClient(server.URL,
+		SetUserAgent("my-app/1.0"),
+		SetDefaultRealm("realm"),
+		SetTimeout(time.Second),
+		SetConnectTimeout(time.Second),
+		SetLogger(logger),
+		SetDebug(true),
+	)
+	_, err := client.GetIssuer(context.Background(), "")
+	assert.NoError(t, err)
+	r := <-requests
+	assert.Equal(t, "/auth/realms/realm", r.URL.Path)
+	assert.Equal(t, "my-app/1.0", r.Header.Get("User-Agent"))
+	assert.NotZero(t, atomic.LoadInt32(&logger.messages))
+
+	_, err = client.GetIssuer(context.Background(), "other")
+	assert.NoError(t, err)
+	assert.Equal(t, "/auth/realms/other", (<-requests).URL.Path)
+}
+
+func TestNewClient_CABundle(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	_, err := NewClient(server.URL).GetIssuer(context.Background(), "realm")
+	assert.Error(t, err)
+
+	client := NewClient(server.URL,
+		SetCABundle(ca),
+		SetTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}),
+	)
+	_, err = client.GetIssuer(context.Background(), "realm")
+	assert.NoError(t, err)
+
+	// the pool of the caller is not changed
+	pool := x509.NewCertPool()
+	config := &tls.Config{RootCAs: pool}
+	_, err = NewClient(server.URL, SetTLSConfig(config), SetCABundle(ca)).GetIssuer(context.Background(), "realm")
+	assert.Error(t, err)
+	assert.True(t, pool == config.RootCAs)
+	assert.Empty(t, pool.Subjects())
+
+	// the error is logged when the client is created
+	logger := &bufferLogger{}
+	client = NewClient(server.URL, SetCABundle([]byte("no certificate")), SetLogger(logger))
+	assert.Contains(t, logger.String(), "the CA bundle 1 contains no certificate")
+	_, err = client.GetIssuer(context.Background(), "realm")
+	assert.EqualError(t, err, "the CA bundle 1 contains no certificate")
+}
+
+func TestNewClient_DefaultRealmSecretAndAuthenticator(t *testing.T) {
+	t.Parallel()
+	client := NewClient("http://localhost", SetDefaultRealm("realm")).(*gocloak)
+	client.SetRealmSecret("", []byte("secret"))
+	assert.Equal(t, []byte("secret"), client.getRealmSecret("realm"))
+
+	authenticator := NewClientSecretPostAuthenticator("secret")
+	client.SetClientAuthenticator("", "my-client", authenticator)
+	assert.Equal(t, authenticator, client.getClientAuthenticator("realm", "my-client"))
+}
+
+func TestNewClient_DefaultRealmRealms(t *testing.T) {
+	t.Parallel()
+	requests := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r.Method + " " + r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			w.Header().Set("Location", "http://"+r.Host+r.URL.Path+"/new")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"realm":"realm"}]`))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, SetDefaultRealm("realm"))
+
+	realms, err := client.GetRealms(context.Background(), "token")
+	assert.NoError(t, err)
+	assert.Len(t, realms, 1)
+	assert.Equal(t, "GET /auth/admin/realms", <-requests)
+
+	id, err := client.CreateRealm(context.Background(), "token", RealmRepresentation{Realm: StringP("new")})
+	assert.NoError(t, err)
+	assert.Equal(t, "new", id)
+	assert.Equal(t, "POST /auth/admin/realms", <-requests)
+}
+
+func TestNewClient_Proxy(t *testing.T) {
+	t.Parallel()
+	hosts := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts <- r.Host
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	client := NewClient("http://keycloak.invalid", SetProxy(proxy.URL))
+	_, err := client.GetIssuer(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, "keycloak.invalid", <-hosts)
+}
+
+func TestNewClient_RetryPolicy(t *testing.T) {
+	t.Parallel()
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			// close the connection without a response
+			hijacker, _ := w.(http.Hijacker)
+			conn, _, err := hijacker.Hijack()
+			assert.NoError(t, err)
+			_ = conn.Close()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"realm":"realm"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, SetRetryPolicy(RetryPolicy{
+		MaxRetries:  2,
+		WaitTime:    time.Millisecond,
+		MaxWaitTime: 10 * time.Millisecond,
+	}))
+	issuer, err := client.GetIssuer(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, "realm", PString(issuer.Realm))
+	assert.EqualValues(t, 3, atomic.LoadInt32(&attempts))
+}
+
+func TestGocloak_SetRestyClientAppliesOptions(t *testing.T) {
+	t.Parallel()
+	var attempts int32
+	userAgents := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents <- r.Header.Get("User-Agent")
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"realm":"realm"}`)
+	}))
+	defer server.Close()
+	instrumentation := &recordingInstrumentation{}
+
+	client := NewClient(server.URL,
+		SetUserAgent("my-app/1.0"),
+		SetRetryPolicy(RetryPolicy{MaxRetries: 1, WaitTime: time.Millisecond}),
+		SetInstrumentation(instrumentation),
+	)
+	restyClient := resty.New()
+	client.SetRestyClient(restyClient)
+	assert.Equal(t, restyClient, client.RestyClient())
+
+	_, err := client.GetIssuer(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&attempts))
+	assert.Equal(t, "my-app/1.0", <-userAgents)
+	if assert.Len(t, instrumentation.infos, 1) {
+		assert.Equal(t, 1, instrumentation.infos[0].Retries)
+	}
+}
@@ -1,8 +1,10 @@
 package gocloak
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -20,6 +22,7 @@ import (
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/go-resty/resty/v2"
+	"github.com/kkovarik/gocloak/pkg/jwx"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -118,6 +121,7 @@ func GetConfig(t *testing.T) *Config {
 func GetClientToken(t *testing.T, client GoCloak) *JWT {
 	cfg := GetConfig(t)
 	token, err := client.LoginClient(
+		context.Background(),
 		cfg.GoCloak.ClientID,
 		cfg.GoCloak.ClientSecret,
 		cfg.GoCloak.Realm)
@@ -129,6 +133,7 @@ func GetUserToken(t *testing.T, client GoCloak) *JWT {
 	SetUpTestUser(t, client)
 	cfg := GetConfig(t)
 	token, err := client.Login(
+		context.Background(),
 		cfg.GoCloak.ClientID,
 		cfg.GoCloak.ClientSecret,
 		cfg.GoCloak.Realm,
@@ -141,6 +146,7 @@ func GetUserToken(t *testing.T, client GoCloak) *JWT {
 func GetAdminToken(t *testing.T, client GoCloak) *JWT {
 	cfg := GetConfig(t)
 	token, err := client.LoginAdmin(
+		context.Background(),
 		cfg.Admin.UserName,
 		cfg.Admin.Password,
 		cfg.Admin.Realm)
@@ -164,6 +170,7 @@ func GetClientByClientID(t *testing.T, client GoCloak, clientID string) *Client
 	cfg := GetConfig(t)
 	token := GetAdminToken(t, client)
 	clients, err := client.GetClients(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		GetClientsParams{
@@ -193,6 +200,7 @@ func CreateGroup(t *testing.T, client GoCloak) (func(), string) {
 		},
 	}
 	groupID, err := client.CreateGroup(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		group)
@@ -201,6 +209,7 @@ func CreateGroup(t *testing.T, client GoCloak) (func(), string) {
 
 	tearDown := func() {
 		err := client.DeleteGroup(
+			context.Background(),
 			token.AccessToken,
 			cfg.GoCloak.Realm,
 			groupID)
@@ -222,12 +231,14 @@ func SetUpTestUser(t *testing.T, client GoCloak) {
 		}
 
 		createdUserID, err := client.CreateUser(
+			context.Background(),
 			token.AccessToken,
 			cfg.GoCloak.Realm,
 			user)
 		FailIfErr(t, err, "CreateUser failed")
 		if IsObjectAlreadyExists(err) {
 			users, err := client.GetUsers(
+				context.Background(),
 				token.AccessToken,
 				cfg.GoCloak.Realm,
 				GetUsersParams{
@@ -246,6 +257,7 @@ func SetUpTestUser(t *testing.T, client GoCloak) {
 		}
 
 		err = client.SetPassword(
+			context.Background(),
 			token.AccessToken,
 			testUserID,
 			cfg.GoCloak.Realm,
@@ -337,7 +349,7 @@ func ClearRealmCache(t *testing.T, client GoCloak, realm ...string) {
 		realm = append(realm, cfg.Admin.Realm, cfg.GoCloak.Realm)
 	}
 	for _, r := range realm {
-		err := client.ClearRealmCache(token.AccessToken, r)
+		err := client.ClearRealmCache(context.Background(), token.AccessToken, r)
 		assert.NoError(t, err, "ClearRealmCache failed for a realm: %s", r)
 	}
 }
@@ -366,11 +378,26 @@ func TestGocloak_checkForError(t *testing.T) {
 	t.Parallel()
 	client := NewClientWithDebug(t)
 	FailRequest(client, nil, 1, 0)
-	_, err := client.Login("", "", "", "", "")
+	_, err := client.Login(context.Background(), "", "", "", "", "")
 	assert.Error(t, err, "All requests must fail with NewClientWithError")
 	t.Logf("Error: %s", err.Error())
 }
 
+func TestGocloak_CanceledContext(t *testing.T) {
+	t.Parallel()
+	cfg := GetConfig(t)
+	client := NewClientWithDebug(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.LoginClient(
+		ctx,
+		cfg.GoCloak.ClientID,
+		cfg.GoCloak.ClientSecret,
+		cfg.GoCloak.Realm)
+	assert.Error(t, err, "Request with a canceled context must fail")
+	assert.True(t, errors.Is(err, context.Canceled), "Unexpected error: %v", err)
+}
+
 // ---------
 // API tests
 // ---------
@@ -380,6 +407,7 @@ func TestGocloak_GetServerInfo(t *testing.T) {
 	client := NewClientWithDebug(t)
 	token := GetAdminToken(t, client)
 	serverInfo, err := client.GetServerInfo(
+		context.Background(),
 		token.AccessToken,
 	)
 	assert.NoError(t, err, "Failed to fetch server info")
@@ -387,6 +415,7 @@ func TestGocloak_GetServerInfo(t *testing.T) {
 
 	FailRequest(client, nil, 1, 0)
 	_, err = client.GetServerInfo(
+		context.Background(),
 		token.AccessToken,
 	)
 	assert.Error(t, err)
@@ -398,12 +427,14 @@ func TestGocloak_GetUserInfo(t *testing.T) {
 	client := NewClientWithDebug(t)
 	token := GetClientToken(t, client)
 	userInfo, err := client.GetUserInfo(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm)
 	assert.NoError(t, err, "Failed to fetch userinfo")
 	t.Log(userInfo)
 	FailRequest(client, nil, 1, 0)
 	_, err = client.GetUserInfo(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm)
 	assert.Error(t, err, "")
@@ -415,6 +446,7 @@ func TestGocloak_RequestPermission(t *testing.T) {
 	client := NewClientWithDebug(t)
 	SetUpTestUser(t, client)
 	token, err := client.RequestPermission(
+		context.Background(),
 		cfg.GoCloak.ClientID,
 		cfg.GoCloak.ClientSecret,
 		cfg.GoCloak.Realm,
@@ -424,6 +456,7 @@ func TestGocloak_RequestPermission(t *testing.T) {
 	FailIfErr(t, err, "login failed")
 
 	rptResult, err := client.RetrospectToken(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.ClientID,
 		cfg.GoCloak.ClientSecret,
@@ -437,7 +470,7 @@ func TestGocloak_GetCerts(t *testing.T) {
 	t.Parallel()
 	cfg := GetConfig(t)
 	client := NewClientWithDebug(t)
-	certs, err := client.GetCerts(cfg.GoCloak.Realm)
+	certs, err := client.GetCerts(context.Background(), cfg.GoCloak.Realm)
 	FailIfErr(t, err, "get certs")
 	t.Log(certs)
 }
@@ -447,6 +480,7 @@ func TestGocloak_LoginClient_UnknownRealm(t *testing.T) {
 	cfg := GetConfig(t)
 	client := NewClientWithDebug(t)
 	_, err := client.LoginClient(
+		context.Background(),
 		cfg.GoCloak.ClientID,
 		cfg.GoCloak.ClientSecret,
 		"ThisRealmDoesNotExist")
@@ -458,7 +492,7 @@ func TestGocloak_GetIssuer(t *testing.T) {
 	t.Parallel()
 	cfg := GetConfig(t)
 	client := NewClientWithDebug(t)
-	issuer, err := client.GetIssuer(cfg.GoCloak.Realm)
+	issuer, err := client.GetIssuer(context.Background(), cfg.GoCloak.Realm)
 	t.Log(issuer)
 	FailIfErr(t, err, "get issuer")
 }
@@ -469,6 +503,7 @@ func TestGocloak_RetrospectToken_InactiveToken(t *testing.T) {
 	client := NewClientWithDebug(t)
 
 	rptResult, err := client.RetrospectToken(
+		context.Background(),
 		"foobar",
 		cfg.GoCloak.ClientID,
 		cfg.GoCloak.ClientSecret,
@@ -486,6 +521,7 @@ func TestGocloak_RetrospectToken(t *testing.T) {
 	token := GetClientToken(t, client)
 
 	rptResult, err := client.RetrospectToken(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.ClientID,
 		cfg.GoCloak.ClientSecret,
@@ -502,6 +538,7 @@ func TestGocloak_DecodeAccessToken(t *testing.T) {
 	token := GetClientToken(t, client)
 
 	resultToken, claims, err := client.DecodeAccessToken(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 	)
@@ -518,6 +555,7 @@ func TestGocloak_DecodeAccessTokenCustomClaims(t *testing.T) {
 
 	claims := jwt.MapClaims{}
 	resultToken, err := client.DecodeAccessTokenCustomClaims(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		claims,
@@ -527,6 +565,31 @@ func TestGocloak_DecodeAccessTokenCustomClaims(t *testing.T) {
 	t.Log(claims)
 }
 
+func TestGocloak_DecodeAccessTokenWithValidation(t *testing.T) {
+	t.Parallel()
+	cfg := GetConfig(t)
+	client := NewClientWithDebug(t)
+	token := GetClientToken(t, client)
+
+	_, _, err := client.DecodeAccessToken(
+		context.Background(),
+		token.AccessToken,
+		cfg.GoCloak.Realm,
+		jwx.WithAuthorizedParty(cfg.GoCloak.ClientID),
+		jwx.WithTokenTypes("Bearer"),
+	)
+	assert.NoError(t, err)
+
+	_, _, err = client.DecodeAccessToken(
+		context.Background(),
+		token.AccessToken,
+		cfg.GoCloak.Realm,
+		jwx.WithAuthorizedParty("ThisClientDoesNotExist"),
+	)
+	var partyErr *jwx.InvalidAuthorizedPartyError
+	assert.True(t, errors.As(err, &partyErr), "Unexpected error: %v", err)
+}
+
 func TestGocloak_RefreshToken(t *testing.T) {
 	t.Parallel()
 	cfg := GetConfig(t)
@@ -534,6 +597,7 @@ func TestGocloak_RefreshToken(t *testing.T) {
 	token := GetClientToken(t, client)
 
 	token, err := client.RefreshToken(
+		context.Background(),
 		token.RefreshToken,
 		cfg.GoCloak.ClientID,
 		cfg.GoCloak.ClientSecret,
@@ -561,6 +625,7 @@ func TestGocloak_GetKeyStoreConfig(t *testing.T) {
 	token := GetAdminToken(t, client)
 
 	config, err := client.GetKeyStoreConfig(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm)
 	t.Log(config)
@@ -573,6 +638,7 @@ func TestGocloak_Login(t *testing.T) {
 	client := NewClientWithDebug(t)
 	SetUpTestUser(t, client)
 	_, err := client.Login(
+		context.Background(),
 		cfg.GoCloak.ClientID,
 		cfg.GoCloak.ClientSecret,
 		cfg.GoCloak.Realm,
@@ -587,6 +653,7 @@ func TestGocloak_GetToken(t *testing.T) {
 	client := NewClientWithDebug(t)
 	SetUpTestUser(t, client)
 	newToken, err := client.GetToken(
+		context.Background(),
 		cfg.GoCloak.Realm,
 		TokenOptions{
 			ClientID:      &cfg.GoCloak.ClientID,
@@ -609,6 +676,7 @@ func TestGocloak_LoginClient(t *testing.T) {
 	cfg := GetConfig(t)
 	client := NewClientWithDebug(t)
 	_, err := client.LoginClient(
+		context.Background(),
 		cfg.GoCloak.ClientID,
 		cfg.GoCloak.ClientSecret,
 		cfg.GoCloak.Realm)
@@ -620,6 +688,7 @@ func TestGocloak_LoginAdmin(t *testing.T) {
 	cfg := GetConfig(t)
 	client := NewClientWithDebug(t)
 	_, err := client.LoginAdmin(
+		context.Background(),
 		cfg.Admin.UserName,
 		cfg.Admin.Password,
 		cfg.Admin.Realm)
@@ -636,6 +705,7 @@ func TestGocloak_SetPassword(t *testing.T) {
 	defer tearDown()
 
 	err := client.SetPassword(
+		context.Background(),
 		token.AccessToken,
 		userID,
 		cfg.GoCloak.Realm,
@@ -657,6 +727,7 @@ func TestGocloak_CreateListGetUpdateDeleteGetChildGroup(t *testing.T) {
 
 	// List
 	createdGroup, err := client.GetGroup(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		groupID,
@@ -666,6 +737,7 @@ func TestGocloak_CreateListGetUpdateDeleteGetChildGroup(t *testing.T) {
 	assert.Equal(t, groupID, *(createdGroup.ID))
 
 	err = client.UpdateGroup(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		Group{},
@@ -674,6 +746,7 @@ func TestGocloak_CreateListGetUpdateDeleteGetChildGroup(t *testing.T) {
 
 	createdGroup.Name = GetRandomNameP("GroupName")
 	err = client.UpdateGroup(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		*createdGroup,
@@ -681,6 +754,7 @@ func TestGocloak_CreateListGetUpdateDeleteGetChildGroup(t *testing.T) {
 	assert.NoError(t, err, "UpdateGroup failed")
 
 	updatedGroup, err := client.GetGroup(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		groupID,
@@ -689,6 +763,7 @@ func TestGocloak_CreateListGetUpdateDeleteGetChildGroup(t *testing.T) {
 	assert.Equal(t, *(createdGroup.Name), *(updatedGroup.Name))
 
 	childGroupID, err := client.CreateChildGroup(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		groupID,
@@ -699,6 +774,7 @@ func TestGocloak_CreateListGetUpdateDeleteGetChildGroup(t *testing.T) {
 	assert.NoError(t, err, "CreateChildGroup failed")
 
 	_, err = client.GetGroup(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		childGroupID,
@@ -713,6 +789,7 @@ func CreateClientRole(t *testing.T, client GoCloak) (func(), string) {
 	roleName := GetRandomName("Role")
 	t.Logf("Creating Client Role: %s", roleName)
 	clientRoleID, err := client.CreateClientRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -725,6 +802,7 @@ func CreateClientRole(t *testing.T, client GoCloak) (func(), string) {
 	assert.NoError(t, err, "CreateClientRole failed")
 	tearDown := func() {
 		err := client.DeleteClientRole(
+			context.Background(),
 			token.AccessToken,
 			cfg.GoCloak.Realm,
 			gocloakClientID,
@@ -749,6 +827,7 @@ func TestGocloak_GetClientRole(t *testing.T) {
 	cfg := GetConfig(t)
 	token := GetAdminToken(t, client)
 	role, err := client.GetClientRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -758,6 +837,7 @@ func TestGocloak_GetClientRole(t *testing.T) {
 	assert.NotNil(t, role)
 	token = GetAdminToken(t, client)
 	role, err = client.GetClientRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -780,6 +860,7 @@ func CreateClientScope(t *testing.T, client GoCloak, scope *ClientScope) (func()
 
 	t.Logf("Creating Client Scope: %+v", scope)
 	clientScopeID, err := client.CreateClientScope(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		*scope,
@@ -790,6 +871,7 @@ func CreateClientScope(t *testing.T, client GoCloak, scope *ClientScope) (func()
 	assert.NoError(t, err, "CreateClientScope failed")
 	tearDown := func() {
 		err := client.DeleteClientScope(
+			context.Background(),
 			token.AccessToken,
 			cfg.GoCloak.Realm,
 			clientScopeID,
@@ -827,6 +909,7 @@ func TestGocloak_ListAddRemoveDefaultClientScopes(t *testing.T) {
 	defer tearDown()
 
 	scopesBeforeAdding, err := client.GetClientsDefaultScopes(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -834,6 +917,7 @@ func TestGocloak_ListAddRemoveDefaultClientScopes(t *testing.T) {
 	assert.NoError(t, err, "GetClientsDefaultScopes failed")
 
 	err = client.AddDefaultScopeToClient(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -842,6 +926,7 @@ func TestGocloak_ListAddRemoveDefaultClientScopes(t *testing.T) {
 	assert.NoError(t, err, "AddDefaultScopeToClient failed")
 
 	scopesAfterAdding, err := client.GetClientsDefaultScopes(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -851,6 +936,7 @@ func TestGocloak_ListAddRemoveDefaultClientScopes(t *testing.T) {
 	assert.NotEqual(t, len(scopesBeforeAdding), len(scopesAfterAdding), "scope should have been added")
 
 	err = client.RemoveDefaultScopeFromClient(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -859,6 +945,7 @@ func TestGocloak_ListAddRemoveDefaultClientScopes(t *testing.T) {
 	assert.NoError(t, err, "RemoveDefaultScopeFromClient failed")
 
 	scopesAfterRemoving, err := client.GetClientsDefaultScopes(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -886,19 +973,20 @@ func TestGocloak_ListAddRemoveOptionalClientScopes(t *testing.T) {
 	tearDown, scopeID := CreateClientScope(t, client, &scope)
 	defer tearDown()
 
-	scopesBeforeAdding, err := client.GetClientsOptionalScopes(token.AccessToken,
+	scopesBeforeAdding, err := client.GetClientsOptionalScopes(context.Background(), token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID)
 	assert.NoError(t, err, "GetClientsOptionalScopes failed")
 
 	err = client.AddOptionalScopeToClient(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
 		scopeID)
 	assert.NoError(t, err, "AddOptionalScopeToClient failed")
 
-	scopesAfterAdding, err := client.GetClientsOptionalScopes(token.AccessToken,
+	scopesAfterAdding, err := client.GetClientsOptionalScopes(context.Background(), token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID)
 	assert.NoError(t, err, "GetClientsOptionalScopes failed")
@@ -906,13 +994,14 @@ func TestGocloak_ListAddRemoveOptionalClientScopes(t *testing.T) {
 	assert.NotEqual(t, len(scopesAfterAdding), len(scopesBeforeAdding), "scope should have been added")
 
 	err = client.RemoveOptionalScopeFromClient(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
 		scopeID)
 	assert.NoError(t, err, "RemoveOptionalScopeFromClient failed")
 
-	scopesAfterRemoving, err := client.GetClientsOptionalScopes(token.AccessToken,
+	scopesAfterRemoving, err := client.GetClientsOptionalScopes(context.Background(), token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID)
 	assert.NoError(t, err, "GetClientsOptionalScopes failed")
@@ -927,6 +1016,7 @@ func TestGocloak_GetDefaultOptionalClientScopes(t *testing.T) {
 	token := GetAdminToken(t, client)
 
 	scopes, err := client.GetDefaultOptionalClientScopes(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm)
 
@@ -942,6 +1032,7 @@ func TestGocloak_GetDefaultDefaultClientScopes(t *testing.T) {
 	token := GetAdminToken(t, client)
 
 	scopes, err := client.GetDefaultDefaultClientScopes(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm)
 
@@ -960,6 +1051,7 @@ func TestGocloak_GetClientScope(t *testing.T) {
 
 	// Getting exact client scope
 	createdClientScope, err := client.GetClientScope(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		scopeID,
@@ -978,6 +1070,7 @@ func TestGocloak_GetClientScopes(t *testing.T) {
 
 	// Getting client scopes
 	scopes, err := client.GetClientScopes(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm)
 	assert.NoError(t, err, "GetClientScopes failed")
@@ -995,6 +1088,7 @@ func TestGocloak_GetClientScopeMappingClientRoles(t *testing.T) {
 
 	// Getting client scope mapping roles
 	roles, err := client.GetClientScopeMappingClientRoles(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		scopeID,
@@ -1015,6 +1109,7 @@ func TestGocloak_AddClientScopeMappingClientRoles(t *testing.T) {
 	roleName := GetRandomName("Role")
 	t.Logf("Creating Client Role: %s", roleName)
 	clientRoleID, err := client.CreateClientRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -1024,12 +1119,14 @@ func TestGocloak_AddClientScopeMappingClientRoles(t *testing.T) {
 	t.Logf("Created Client Role ID: %s", clientRoleID)
 
 	roles, err := client.GetClientRoles(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID)
 
 	// Getting client scope mapping roles
 	err = client.AddClientScopeMappingClientRoles(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		scopeID,
@@ -1040,6 +1137,7 @@ func TestGocloak_AddClientScopeMappingClientRoles(t *testing.T) {
 	assert.NotZero(t, len(roles), "there should be client scopes")
 
 	roles, err = client.GetClientScopeMappingClientRoles(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		scopeID,
@@ -1059,6 +1157,7 @@ func TestGocloak_CreateListGetUpdateDeleteClient(t *testing.T) {
 
 	// Creating a client
 	createdClientID, err := client.CreateClient(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		Client{
@@ -1071,6 +1170,7 @@ func TestGocloak_CreateListGetUpdateDeleteClient(t *testing.T) {
 
 	// Looking for a created client
 	clients, err := client.GetClients(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		GetClientsParams{
@@ -1084,6 +1184,7 @@ func TestGocloak_CreateListGetUpdateDeleteClient(t *testing.T) {
 
 	// Getting exact client
 	createdClient, err := client.GetClient(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		createdClientID,
@@ -1097,6 +1198,7 @@ func TestGocloak_CreateListGetUpdateDeleteClient(t *testing.T) {
 
 	// Should fail
 	err = client.UpdateClient(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		Client{},
@@ -1106,6 +1208,7 @@ func TestGocloak_CreateListGetUpdateDeleteClient(t *testing.T) {
 	// Update existing client
 	createdClient.Name = GetRandomNameP("Name")
 	err = client.UpdateClient(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		*createdClient,
@@ -1114,6 +1217,7 @@ func TestGocloak_CreateListGetUpdateDeleteClient(t *testing.T) {
 
 	// Getting updated client
 	updatedClient, err := client.GetClient(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		createdClientID,
@@ -1124,6 +1228,7 @@ func TestGocloak_CreateListGetUpdateDeleteClient(t *testing.T) {
 
 	// Deleting the client
 	err = client.DeleteClient(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		createdClientID,
@@ -1132,6 +1237,7 @@ func TestGocloak_CreateListGetUpdateDeleteClient(t *testing.T) {
 
 	// Verifying that the client was deleted
 	clients, err = client.GetClients(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		GetClientsParams{
@@ -1149,6 +1255,7 @@ func TestGocloak_GetGroups(t *testing.T) {
 	token := GetAdminToken(t, client)
 
 	_, err := client.GetGroups(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		GetGroupsParams{})
@@ -1165,6 +1272,7 @@ func TestGocloak_GetGroupsFull(t *testing.T) {
 	defer tearDown()
 
 	groups, err := client.GetGroups(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		GetGroupsParams{
@@ -1196,6 +1304,7 @@ func TestGocloak_GetGroupFull(t *testing.T) {
 	defer tearDown()
 
 	createdGroup, err := client.GetGroup(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		groupID,
@@ -1218,6 +1327,7 @@ func TestGocloak_GetGroupMembers(t *testing.T) {
 	defer tearDownGroup()
 
 	err := client.AddUserToGroup(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID,
@@ -1226,6 +1336,7 @@ func TestGocloak_GetGroupMembers(t *testing.T) {
 	assert.NoError(t, err, "AddUserToGroup failed")
 
 	users, err := client.GetGroupMembers(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		groupID,
@@ -1249,6 +1360,7 @@ func TestGocloak_GetClientRoles(t *testing.T) {
 	testClient := GetClientByClientID(t, client, cfg.GoCloak.ClientID)
 
 	_, err := client.GetClientRoles(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		*(testClient.ID))
@@ -1265,6 +1377,7 @@ func TestGocloak_GetRoleMappingByGroupID(t *testing.T) {
 	defer tearDown()
 
 	_, err := client.GetRoleMappingByGroupID(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		groupID)
@@ -1281,6 +1394,7 @@ func TestGocloak_GetRoleMappingByUserID(t *testing.T) {
 	defer tearDown()
 
 	_, err := client.GetRoleMappingByUserID(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID)
@@ -1303,6 +1417,7 @@ func TestGocloak_ExecuteActionsEmail_UpdatePassword(t *testing.T) {
 	}
 
 	err := client.ExecuteActionsEmail(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		params)
@@ -1322,6 +1437,7 @@ func TestGocloak_Logout(t *testing.T) {
 	token := GetUserToken(t, client)
 
 	err := client.Logout(
+		context.Background(),
 		cfg.GoCloak.ClientID,
 		cfg.GoCloak.ClientSecret,
 		cfg.GoCloak.Realm,
@@ -1336,6 +1452,7 @@ func TestGocloak_GetRealm(t *testing.T) {
 	token := GetAdminToken(t, client)
 
 	r, err := client.GetRealm(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm)
 	t.Logf("%+v", r)
@@ -1347,7 +1464,7 @@ func TestGocloak_GetRealms(t *testing.T) {
 	client := NewClientWithDebug(t)
 	token := GetAdminToken(t, client)
 
-	r, err := client.GetRealms(token.AccessToken)
+	r, err := client.GetRealms(context.Background(), token.AccessToken)
 	t.Logf("%+v", r)
 	FailIfErr(t, err, "GetRealms failed")
 }
@@ -1362,6 +1479,7 @@ func CreateRealm(t *testing.T, client GoCloak) (func(), string) {
 	realmName := GetRandomName("Realm")
 	t.Logf("Creating Realm: %s", realmName)
 	realmID, err := client.CreateRealm(
+		context.Background(),
 		token.AccessToken,
 		RealmRepresentation{
 			Realm: &realmName,
@@ -1370,6 +1488,7 @@ func CreateRealm(t *testing.T, client GoCloak) (func(), string) {
 	assert.Equal(t, realmID, realmName)
 	tearDown := func() {
 		err := client.DeleteRealm(
+			context.Background(),
 			token.AccessToken,
 			realmName)
 		assert.NoError(t, err, "DeleteRealm failed")
@@ -1401,6 +1520,7 @@ func CreateRealmRole(t *testing.T, client GoCloak) (func(), string) {
 	roleName := GetRandomName("Role")
 	t.Logf("Creating RoleName: %s", roleName)
 	realmRoleID, err := client.CreateRealmRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		Role{
@@ -1411,6 +1531,7 @@ func CreateRealmRole(t *testing.T, client GoCloak) (func(), string) {
 	assert.Equal(t, roleName, realmRoleID)
 	tearDown := func() {
 		err := client.DeleteRealmRole(
+			context.Background(),
 			token.AccessToken,
 			cfg.GoCloak.Realm,
 			roleName)
@@ -1436,6 +1557,7 @@ func TestGocloak_GetRealmRole(t *testing.T) {
 	defer tearDown()
 
 	role, err := client.GetRealmRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		roleName)
@@ -1458,6 +1580,7 @@ func TestGocloak_GetRealmRoles(t *testing.T) {
 	defer tearDown()
 
 	roles, err := client.GetRealmRoles(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm)
 	FailIfErr(t, err, "GetRealmRoles failed")
@@ -1474,6 +1597,7 @@ func TestGocloak_UpdateRealmRole(t *testing.T) {
 	_, oldRoleName := CreateRealmRole(t, client)
 
 	err := client.UpdateRealmRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		oldRoleName,
@@ -1482,6 +1606,7 @@ func TestGocloak_UpdateRealmRole(t *testing.T) {
 		})
 	assert.NoError(t, err, "UpdateRealmRole failed")
 	err = client.DeleteRealmRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		oldRoleName)
@@ -1491,6 +1616,7 @@ func TestGocloak_UpdateRealmRole(t *testing.T) {
 		"Role with old name was deleted successfully, but it shouldn't. Old role: %s; Updated role: %s",
 		oldRoleName, newRoleName)
 	err = client.DeleteRealmRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		newRoleName)
@@ -1506,6 +1632,7 @@ func TestGocloak_DeleteRealmRole(t *testing.T) {
 	_, roleName := CreateRealmRole(t, client)
 
 	err := client.DeleteRealmRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		roleName)
@@ -1523,6 +1650,7 @@ func TestGocloak_AddRealmRoleToUser_DeleteRealmRoleFromUser(t *testing.T) {
 	tearDownRole, roleName := CreateRealmRole(t, client)
 	defer tearDownRole()
 	role, err := client.GetRealmRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		roleName)
@@ -1530,6 +1658,7 @@ func TestGocloak_AddRealmRoleToUser_DeleteRealmRoleFromUser(t *testing.T) {
 
 	roles := []Role{*role}
 	err = client.AddRealmRoleToUser(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID,
@@ -1537,6 +1666,7 @@ func TestGocloak_AddRealmRoleToUser_DeleteRealmRoleFromUser(t *testing.T) {
 	)
 	assert.NoError(t, err, "AddRealmRoleToUser failed")
 	err = client.DeleteRealmRoleFromUser(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID,
@@ -1556,12 +1686,14 @@ func TestGocloak_GetRealmRolesByUserID(t *testing.T) {
 	tearDownRole, roleName := CreateRealmRole(t, client)
 	defer tearDownRole()
 	role, err := client.GetRealmRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		roleName)
 	assert.NoError(t, err)
 
 	err = client.AddRealmRoleToUser(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID,
@@ -1571,6 +1703,7 @@ func TestGocloak_GetRealmRolesByUserID(t *testing.T) {
 	assert.NoError(t, err)
 
 	roles, err := client.GetRealmRolesByUserID(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID)
@@ -1597,6 +1730,7 @@ func TestGocloak_GetRealmRolesByGroupID(t *testing.T) {
 	defer tearDown()
 
 	_, err := client.GetRealmRolesByGroupID(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		groupID)
@@ -1615,10 +1749,10 @@ func TestGocloak_AddRealmRoleComposite(t *testing.T) {
 	tearDown, role := CreateRealmRole(t, client)
 	defer tearDown()
 
-	roleModel, err := client.GetRealmRole(token.AccessToken, cfg.GoCloak.Realm, role)
+	roleModel, err := client.GetRealmRole(context.Background(), token.AccessToken, cfg.GoCloak.Realm, role)
 	FailIfErr(t, err, "Can't get just created role with GetRealmRole")
 
-	err = client.AddRealmRoleComposite(token.AccessToken,
+	err = client.AddRealmRoleComposite(context.Background(), token.AccessToken,
 		cfg.GoCloak.Realm, compositeRole, []Role{*roleModel})
 	FailIfErr(t, err, "AddRealmRoleComposite failed")
 }
@@ -1635,14 +1769,14 @@ func TestGocloak_DeleteRealmRoleComposite(t *testing.T) {
 	tearDown, role := CreateRealmRole(t, client)
 	defer tearDown()
 
-	roleModel, err := client.GetRealmRole(token.AccessToken, cfg.GoCloak.Realm, role)
+	roleModel, err := client.GetRealmRole(context.Background(), token.AccessToken, cfg.GoCloak.Realm, role)
 	FailIfErr(t, err, "Can't get just created role with GetRealmRole")
 
-	err = client.AddRealmRoleComposite(token.AccessToken,
+	err = client.AddRealmRoleComposite(context.Background(), token.AccessToken,
 		cfg.GoCloak.Realm, compositeRole, []Role{*roleModel})
 	FailIfErr(t, err, "AddRealmRoleComposite failed")
 
-	err = client.DeleteRealmRoleComposite(token.AccessToken,
+	err = client.DeleteRealmRoleComposite(context.Background(), token.AccessToken,
 		cfg.GoCloak.Realm, compositeRole, []Role{*roleModel})
 	FailIfErr(t, err, "DeleteRealmRoleComposite failed")
 }
@@ -1668,6 +1802,7 @@ func CreateUser(t *testing.T, client GoCloak) (func(), string) {
 	user.Username = user.Email
 
 	userID, err := client.CreateUser(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		user)
@@ -1676,6 +1811,7 @@ func CreateUser(t *testing.T, client GoCloak) (func(), string) {
 	t.Logf("Created User: %+v", user)
 	tearDown := func() {
 		err := client.DeleteUser(
+			context.Background(),
 			token.AccessToken,
 			cfg.GoCloak.Realm,
 			*(user.ID))
@@ -1702,7 +1838,7 @@ func TestGocloak_CreateUserCustomAttributes(t *testing.T) {
 	tearDown, userID := CreateUser(t, client)
 	defer tearDown()
 
-	fetchedUser, err := client.GetUserByID(token.AccessToken,
+	fetchedUser, err := client.GetUserByID(context.Background(), token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID)
 	FailIfErr(t, err, "GetUserByID failed")
@@ -1723,6 +1859,7 @@ func TestGocloak_GetUserByID(t *testing.T) {
 	defer tearDown()
 
 	fetchedUser, err := client.GetUserByID(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID)
@@ -1737,6 +1874,7 @@ func TestGocloak_GetUsers(t *testing.T) {
 	token := GetAdminToken(t, client)
 
 	users, err := client.GetUsers(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		GetUsersParams{
@@ -1753,6 +1891,7 @@ func TestGocloak_GetUserCount(t *testing.T) {
 	token := GetAdminToken(t, client)
 
 	count, err := client.GetUserCount(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm)
 	t.Logf("Users in Realm: %d", count)
@@ -1771,6 +1910,7 @@ func TestGocloak_AddUserToGroup(t *testing.T) {
 	defer tearDownGroup()
 
 	err := client.AddUserToGroup(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID,
@@ -1790,6 +1930,7 @@ func TestGocloak_DeleteUserFromGroup(t *testing.T) {
 	tearDownGroup, groupID := CreateGroup(t, client)
 	defer tearDownGroup()
 	err := client.AddUserToGroup(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID,
@@ -1797,6 +1938,7 @@ func TestGocloak_DeleteUserFromGroup(t *testing.T) {
 	)
 	FailIfErr(t, err, "AddUserToGroup failed")
 	err = client.DeleteUserFromGroup(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID,
@@ -1818,6 +1960,7 @@ func TestGocloak_GetUserGroups(t *testing.T) {
 	defer tearDownGroup()
 
 	err := client.AddUserToGroup(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID,
@@ -1825,6 +1968,7 @@ func TestGocloak_GetUserGroups(t *testing.T) {
 	)
 	assert.NoError(t, err)
 	groups, err := client.GetUserGroups(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID)
@@ -1857,12 +2001,14 @@ func TestGocloak_UpdateUser(t *testing.T) {
 	tearDown, userID := CreateUser(t, client)
 	defer tearDown()
 	user, err := client.GetUserByID(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID)
 	FailIfErr(t, err, "GetUserByID failed")
 	user.FirstName = GetRandomNameP("UpdateUserFirstName")
 	err = client.UpdateUser(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		*user)
@@ -1878,6 +2024,7 @@ func TestGocloak_UpdateUserSetEmptyEmail(t *testing.T) {
 	tearDown, userID := CreateUser(t, client)
 	defer tearDown()
 	user, err := client.GetUserByID(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID,
@@ -1885,11 +2032,13 @@ func TestGocloak_UpdateUserSetEmptyEmail(t *testing.T) {
 	assert.NoError(t, err)
 	user.Email = StringP("")
 	err = client.UpdateUser(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		*user)
 	assert.NoError(t, err)
 	user, err = client.GetUserByID(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID,
@@ -1911,11 +2060,13 @@ func TestGocloak_GetUsersByRoleName(t *testing.T) {
 	defer tearDownRole()
 
 	role, err := client.GetRealmRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		roleName)
 	assert.NoError(t, err)
 	err = client.AddRealmRoleToUser(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		userID,
@@ -1925,6 +2076,7 @@ func TestGocloak_GetUsersByRoleName(t *testing.T) {
 	assert.NoError(t, err)
 
 	users, err := client.GetUsersByRoleName(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		roleName)
@@ -1948,6 +2100,7 @@ func TestGocloak_GetUserSessions(t *testing.T) {
 	client := NewClientWithDebug(t)
 	SetUpTestUser(t, client)
 	_, err := client.GetToken(
+		context.Background(),
 		cfg.GoCloak.Realm,
 		TokenOptions{
 			ClientID:     &(cfg.GoCloak.ClientID),
@@ -1960,6 +2113,7 @@ func TestGocloak_GetUserSessions(t *testing.T) {
 	FailIfErr(t, err, "Login failed")
 	token := GetAdminToken(t, client)
 	sessions, err := client.GetUserSessions(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		testUserID,
@@ -1974,6 +2128,7 @@ func TestGocloak_GetUserOfflineSessionsForClient(t *testing.T) {
 	client := NewClientWithDebug(t)
 	SetUpTestUser(t, client)
 	_, err := client.GetToken(
+		context.Background(),
 		cfg.GoCloak.Realm,
 		TokenOptions{
 			ClientID:      &(cfg.GoCloak.ClientID),
@@ -1988,6 +2143,7 @@ func TestGocloak_GetUserOfflineSessionsForClient(t *testing.T) {
 	FailIfErr(t, err, "Login failed")
 	token := GetAdminToken(t, client)
 	sessions, err := client.GetUserOfflineSessionsForClient(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		testUserID,
@@ -2003,6 +2159,7 @@ func TestGocloak_GetClientUserSessions(t *testing.T) {
 	client := NewClientWithDebug(t)
 	SetUpTestUser(t, client)
 	_, err := client.GetToken(
+		context.Background(),
 		cfg.GoCloak.Realm,
 		TokenOptions{
 			ClientID:     &(cfg.GoCloak.ClientID),
@@ -2015,6 +2172,7 @@ func TestGocloak_GetClientUserSessions(t *testing.T) {
 	FailIfErr(t, err, "Login failed")
 	token := GetAdminToken(t, client)
 	sessions, err := client.GetClientUserSessions(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -2048,6 +2206,7 @@ func TestGocloak_CreateDeleteClientProtocolMapper(t *testing.T) {
 
 	token := GetAdminToken(t, client)
 	createdID, err := client.CreateClientProtocolMapper(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		*(testClient.ID),
@@ -2088,6 +2247,7 @@ func TestGocloak_CreateDeleteClientProtocolMapper(t *testing.T) {
 		"protocol mapper has not been created",
 	)
 	err = client.DeleteClientProtocolMapper(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		*(testClient.ID),
@@ -2119,6 +2279,7 @@ func TestGocloak_GetClientOfflineSessions(t *testing.T) {
 	client := NewClientWithDebug(t)
 	SetUpTestUser(t, client)
 	_, err := client.GetToken(
+		context.Background(),
 		cfg.GoCloak.Realm,
 		TokenOptions{
 			ClientID:      &(cfg.GoCloak.ClientID),
@@ -2133,6 +2294,7 @@ func TestGocloak_GetClientOfflineSessions(t *testing.T) {
 	FailIfErr(t, err, "Login failed")
 	token := GetAdminToken(t, client)
 	sessions, err := client.GetClientOfflineSessions(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -2161,6 +2323,7 @@ func TestGoCloak_ClientSecret(t *testing.T) {
 	}
 
 	clientID, err := client.CreateClient(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		testClient,
@@ -2169,6 +2332,7 @@ func TestGoCloak_ClientSecret(t *testing.T) {
 	assert.Equal(t, *(testClient.ID), clientID)
 
 	oldCreds, err := client.GetClientSecret(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		clientID,
@@ -2176,6 +2340,7 @@ func TestGoCloak_ClientSecret(t *testing.T) {
 	assert.NoError(t, err, "GetClientSecret failed")
 
 	regeneratedCreds, err := client.RegenerateClientSecret(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		clientID,
@@ -2184,7 +2349,7 @@ func TestGoCloak_ClientSecret(t *testing.T) {
 
 	assert.NotEqual(t, *(oldCreds.Value), *(regeneratedCreds.Value))
 
-	err = client.DeleteClient(token.AccessToken, cfg.GoCloak.Realm, clientID)
+	err = client.DeleteClient(context.Background(), token.AccessToken, cfg.GoCloak.Realm, clientID)
 	assert.NoError(t, err, "DeleteClient failed")
 }
 
@@ -2194,7 +2359,7 @@ func TestGoCloak_ClientServiceAccount(t *testing.T) {
 	client := NewClientWithDebug(t)
 	token := GetAdminToken(t, client)
 
-	serviceAccount, err := client.GetClientServiceAccount(token.AccessToken, cfg.GoCloak.Realm, gocloakClientID)
+	serviceAccount, err := client.GetClientServiceAccount(context.Background(), token.AccessToken, cfg.GoCloak.Realm, gocloakClientID)
 	assert.NoError(t, err)
 
 	assert.NotNil(t, serviceAccount.ID)
@@ -2212,6 +2377,7 @@ func TestGocloak_AddClientRoleToUser_DeleteClientRoleFromUser(t *testing.T) {
 	defer tearDown1()
 	token := GetAdminToken(t, client)
 	role1, err := client.GetClientRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -2221,6 +2387,7 @@ func TestGocloak_AddClientRoleToUser_DeleteClientRoleFromUser(t *testing.T) {
 	tearDown2, roleName2 := CreateClientRole(t, client)
 	defer tearDown2()
 	role2, err := client.GetClientRole(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -2229,6 +2396,7 @@ func TestGocloak_AddClientRoleToUser_DeleteClientRoleFromUser(t *testing.T) {
 	assert.NoError(t, err, "GetClientRole failed")
 	roles := []Role{*role1, *role2}
 	err = client.AddClientRoleToUser(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -2238,6 +2406,7 @@ func TestGocloak_AddClientRoleToUser_DeleteClientRoleFromUser(t *testing.T) {
 	assert.NoError(t, err, "AddClientRoleToUser failed")
 
 	err = client.DeleteClientRoleFromUser(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		gocloakClientID,
@@ -2259,6 +2428,7 @@ func TestGocloak_CreateDeleteClientScopeWithMappers(t *testing.T) {
 	audiencemapperID := GetRandomName("client-audiencemapper-id-")
 
 	createdID, err := client.CreateClientScope(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		ClientScope{
@@ -2305,18 +2475,19 @@ func TestGocloak_CreateDeleteClientScopeWithMappers(t *testing.T) {
 	)
 	assert.NoError(t, err, "CreateClientScope failed")
 	assert.Equal(t, id, createdID)
-	clientScopeActual, err := client.GetClientScope(token.AccessToken, cfg.GoCloak.Realm, id)
+	clientScopeActual, err := client.GetClientScope(context.Background(), token.AccessToken, cfg.GoCloak.Realm, id)
 	assert.NoError(t, err)
 
 	assert.NotNil(t, clientScopeActual, "client scope has not been created")
 	assert.Len(t, clientScopeActual.ProtocolMappers, 2, "unexpected number of protocol mappers created")
 	err = client.DeleteClientScope(
+		context.Background(),
 		token.AccessToken,
 		cfg.GoCloak.Realm,
 		id,
 	)
 	assert.NoError(t, err, "DeleteClientScope failed")
-	clientScopeActual, err = client.GetClientScope(token.AccessToken, cfg.GoCloak.Realm, id)
+	clientScopeActual, err = client.GetClientScope(context.Background(), token.AccessToken, cfg.GoCloak.Realm, id)
 	assert.EqualError(t, err, "404 Not Found: Could not find client scope")
 	assert.Nil(t, clientScopeActual, "client scope has not been deleted")
 }
@@ -0,0 +1,134 @@
+package gocloak
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+const (
+	// deviceDefaultInterval is the polling interval used if keycloak returns none
+	deviceDefaultInterval = 5 * time.Second
+	// deviceSlowDownStep is added to the polling interval when keycloak answers with slow_down
+	deviceSlowDownStep = 5 * time.Second
+)
+
+// StartDeviceAuthorization starts the device authorization grant (RFC 8628).
+// The user has to open the returned verification URI and enter the user code
+func (client *gocloak) StartDeviceAuthorization(ctx context.Context, clientID, clientSecret, realm string, scopes ...string) (*DeviceAuthorization, error) {
+	formData := map[string]string{
+		"client_id": clientID,
+	}
+	if len(scopes) > 0 {
+		formData["scope"] = strings.Join(scopes, " ")
+	}
+
+	req, err := client.getRequestWithClientAuth(ctx, "StartDeviceAuthorization", realm, clientID, clientSecret, nil)
+	if err != nil {
+		return nil, err
+	}
+	endpointURL, err := client.getEndpointURL(ctx, realm, deviceAuthorizationURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var result DeviceAuthorization
+	resp, err := req.SetFormData(formData).
+		SetResult(&result).
+		Post(endpointURL)
+
+	if err := checkForError(resp, err); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// WaitForDeviceToken polls the token endpoint until the user has approved the device authorization,
+// the authorization has been denied or has expired, or the context is done
+func (client *gocloak) WaitForDeviceToken(ctx context.Context, clientID, clientSecret, realm string, authorization *DeviceAuthorization) (*JWT, error) {
+	interval := time.Duration(authorization.Interval) * time.Second
+	if interval <= 0 {
+		interval = deviceDefaultInterval
+	}
+	var expiresAt time.Time
+	if authorization.ExpiresIn > 0 {
+		expiresAt = client.now().Add(time.Duration(authorization.ExpiresIn) * time.Second)
+	}
+
+	for {
+		wait := interval
+		if !expiresAt.IsZero() {
+			if remaining := expiresAt.Sub(client.now()); remaining < wait {
+				wait = remaining
+			}
+		}
+		if err := client.wait(ctx, wait); err != nil {
+			return nil, err
+		}
+		if !expiresAt.IsZero() && !client.now().Before(expiresAt) {
+			return nil, errors.New("the device authorization has expired")
+		}
+
+		token, errorCode, err := client.pollDeviceToken(ctx, clientID, clientSecret, realm, authorization.DeviceCode)
+		switch errorCode {
+		case "":
+			if err != nil {
+				return nil, err
+			}
+			return token, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += deviceSlowDownStep
+		default:
+			return nil, err
+		}
+	}
+}
+
+// waitContext waits for the duration or until the context is done
+func waitContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// pollDeviceToken requests the token once and returns the OAuth error code of a failed request
+func (client *gocloak) pollDeviceToken(ctx context.Context, clientID, clientSecret, realm, deviceCode string) (*JWT, string, error) {
+	req, err := client.getRequestWithClientAuth(ctx, "WaitForDeviceToken", realm, clientID, clientSecret, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	endpointURL, err := client.getEndpointURL(ctx, realm, tokenURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var token JWT
+	resp, err := req.SetFormData(map[string]string{
+		"client_id":   clientID,
+		"grant_type":  deviceCodeGrantType,
+		"device_code": deviceCode,
+	}).
+		SetResult(&token).
+		Post(endpointURL)
+
+	if err := checkForError(resp, err); err != nil {
+		var errorCode string
+		var apiError *APIError
+		if errors.As(err, &apiError) {
+			errorCode = apiError.ErrorCode
+		}
+		return nil, errorCode, err
+	}
+
+	return &token, "", nil
+}
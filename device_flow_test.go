@@ -0,0 +1,130 @@
+package gocloak
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func deviceFlowServer(t *testing.T, responses []string) (*httptest.Server, *int32) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		assert.NoError(t, r.ParseForm())
+		switch r.URL.Path {
+		case "/auth/realms/realm/protocol/openid-connect/auth/device":
+			assert.Equal(t, "cli", r.PostForm.Get("client_id"))
+			assert.Equal(t, "openid offline_access", r.PostForm.Get("scope"))
+			_ = json.NewEncoder(w).Encode(DeviceAuthorization{
+				DeviceCode:      "device-code",
+				UserCode:        "ABCD-EFGH",
+				VerificationURI: "http://localhost/device",
+				ExpiresIn:       600,
+				Interval:        1,
+			})
+		case "/auth/realms/realm/protocol/openid-connect/token":
+			assert.Equal(t, deviceCodeGrantType, r.PostForm.Get("grant_type"))
+			assert.Equal(t, "device-code", r.PostForm.Get("device_code"))
+			n := int(atomic.AddInt32(&polls, 1))
+			if n <= len(responses) {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": responses[n-1]})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(JWT{AccessToken: "access"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server, &polls
+}
+
+// fakeDeviceClock lets the client wait without sleeping and records the waits
+func fakeDeviceClock(client GoCloak) func() []time.Duration {
+	c := client.(*gocloak)
+	now := time.Unix(1000000, 0)
+	var waits []time.Duration
+	c.now = func() time.Time { return now }
+	c.wait = func(ctx context.Context, d time.Duration) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		waits = append(waits, d)
+		now = now.Add(d)
+		return nil
+	}
+	return func() []time.Duration {
+		return waits
+	}
+}
+
+func TestGocloak_DeviceFlow(t *testing.T) {
+	t.Parallel()
+	server, polls := deviceFlowServer(t, []string{"authorization_pending", "slow_down", "authorization_pending"})
+	defer server.Close()
+	client := NewClient(server.URL)
+	waits := fakeDeviceClock(client)
+
+	authorization, err := client.StartDeviceAuthorization(context.Background(), "cli", "", "realm", "openid", "offline_access")
+	assert.NoError(t, err)
+	assert.Equal(t, "ABCD-EFGH", authorization.UserCode)
+	assert.Equal(t, "http://localhost/device", authorization.VerificationURI)
+
+	token, err := client.WaitForDeviceToken(context.Background(), "cli", "", "realm", authorization)
+	assert.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken)
+	assert.EqualValues(t, 4, atomic.LoadInt32(polls))
+	assert.Equal(t, []time.Duration{time.Second, time.Second, 6 * time.Second, 6 * time.Second}, waits())
+}
+
+func TestGocloak_DeviceFlowDenied(t *testing.T) {
+	t.Parallel()
+	server, polls := deviceFlowServer(t, []string{"authorization_pending", "access_denied"})
+	defer server.Close()
+	client := NewClient(server.URL)
+	waits := fakeDeviceClock(client)
+
+	_, err := client.WaitForDeviceToken(context.Background(), "cli", "", "realm", &DeviceAuthorization{DeviceCode: "device-code"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "access_denied")
+	assert.EqualValues(t, 2, atomic.LoadInt32(polls))
+	assert.Equal(t, []time.Duration{deviceDefaultInterval, deviceDefaultInterval}, waits())
+}
+
+func TestGocloak_DeviceFlowCanceled(t *testing.T) {
+	t.Parallel()
+	pending := make([]string, 1000)
+	for i := range pending {
+		pending[i] = "authorization_pending"
+	}
+	server, polls := deviceFlowServer(t, pending)
+	defer server.Close()
+	client := NewClient(server.URL)
+	waits := fakeDeviceClock(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.WaitForDeviceToken(ctx, "cli", "", "realm", &DeviceAuthorization{DeviceCode: "device-code", Interval: 1})
+	assert.Equal(t, context.Canceled, err)
+	assert.EqualValues(t, 0, atomic.LoadInt32(polls))
+
+	_, err = client.WaitForDeviceToken(context.Background(), "cli", "", "realm", &DeviceAuthorization{DeviceCode: "device-code", Interval: 3, ExpiresIn: 10})
+	assert.EqualError(t, err, "the device authorization has expired")
+	assert.EqualValues(t, 3, atomic.LoadInt32(polls))
+	assert.Equal(t, []time.Duration{3 * time.Second, 3 * time.Second, 3 * time.Second, time.Second}, waits())
+}
+
+func TestWaitContext(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, waitContext(context.Background(), time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, waitContext(ctx, time.Hour))
+}
@@ -0,0 +1,120 @@
+package gocloak
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// discoveryTTL is how long the discovered configuration of a realm is used before it is requested again
+const discoveryTTL = time.Hour
+
+// endpoint is an endpoint of a realm, found in the discovery document or at its default path
+type endpoint struct {
+	path     []string
+	discover func(config *OpenIDConfiguration) *string
+}
+
+var (
+	tokenURL = endpoint{
+		path:     []string{tokenEndpoint},
+		discover: func(config *OpenIDConfiguration) *string { return config.TokenEndpoint },
+	}
+	introspectionURL = endpoint{
+		path:     []string{tokenEndpoint, "introspect"},
+		discover: func(config *OpenIDConfiguration) *string { return config.IntrospectionEndpoint },
+	}
+	userInfoURL = endpoint{
+		path:     []string{openIDConnect, "userinfo"},
+		discover: func(config *OpenIDConfiguration) *string { return config.UserInfoEndpoint },
+	}
+	revocationURL = endpoint{
+		path:     []string{openIDConnect, "revoke"},
+		discover: func(config *OpenIDConfiguration) *string { return config.RevocationEndpoint },
+	}
+	logoutURL = endpoint{
+		path:     []string{logoutEndpoint},
+		discover: func(config *OpenIDConfiguration) *string { return config.EndSessionEndpoint },
+	}
+	jwksURL = endpoint{
+		path:     []string{openIDConnect, "certs"},
+		discover: func(config *OpenIDConfiguration) *string { return config.JwksURI },
+	}
+	deviceAuthorizationURL = endpoint{
+		path:     []string{openIDConnect, "auth", "device"},
+		discover: func(config *OpenIDConfiguration) *string { return config.DeviceAuthorizationEndpoint },
+	}
+	issuerURL = endpoint{
+		discover: func(config *OpenIDConfiguration) *string { return config.Issuer },
+	}
+)
+
+type discoveryEntry struct {
+	config    *OpenIDConfiguration
+	expiresAt time.Time
+}
+
+// discoveryCache keeps the discovered configuration of the realms
+type discoveryCache struct {
+	mu      sync.Mutex
+	entries map[string]discoveryEntry
+}
+
+// SetOpenIDDiscovery enables resolving the token, userinfo, introspection, revocation, logout and certs URLs
+// and the issuer of a realm from its discovery document, so the client works behind proxies that rewrite paths
+func SetOpenIDDiscovery(enabled bool) ClientOption {
+	return func(client *gocloak) {
+		client.Config.OpenIDDiscovery = enabled
+	}
+}
+
+// GetOpenIDConfiguration returns the discovery document of the realm
+func (client *gocloak) GetOpenIDConfiguration(ctx context.Context, realm string) (*OpenIDConfiguration, error) {
+	var result OpenIDConfiguration
+	resp, err := client.getRequest(ctx, "GetOpenIDConfiguration", realm).
+		SetResult(&result).
+		Get(client.getRealmURL(realm, ".well-known", "openid-configuration"))
+
+	if err := checkForError(resp, err); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// getOpenIDConfiguration returns the cached discovery document of the realm or requests it
+func (client *gocloak) getOpenIDConfiguration(ctx context.Context, realm string) (*OpenIDConfiguration, error) {
+	realm = client.getRealm(realm)
+	now := time.Now()
+	client.discovery.mu.Lock()
+	entry, ok := client.discovery.entries[realm]
+	client.discovery.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.config, nil
+	}
+
+	config, err := client.GetOpenIDConfiguration(ctx, realm)
+	if err != nil {
+		return nil, err
+	}
+
+	client.discovery.mu.Lock()
+	client.discovery.entries[realm] = discoveryEntry{config: config, expiresAt: now.Add(discoveryTTL)}
+	client.discovery.mu.Unlock()
+	return config, nil
+}
+
+// getEndpointURL returns the URL of the endpoint of the realm.
+// If discovery is enabled the URL is taken from the discovery document, if it lists the endpoint
+func (client *gocloak) getEndpointURL(ctx context.Context, realm string, endpoint endpoint) (string, error) {
+	if client.Config.OpenIDDiscovery {
+		config, err := client.getOpenIDConfiguration(ctx, realm)
+		if err != nil {
+			return "", err
+		}
+		if url := PString(endpoint.discover(config)); len(url) > 0 {
+			return url, nil
+		}
+	}
+	return client.getRealmURL(realm, endpoint.path...), nil
+}
@@ -0,0 +1,96 @@
+package gocloak
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func discoveryServer(t *testing.T, discoveries *int32) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/auth/realms/realm/.well-known/openid-configuration":
+			atomic.AddInt32(discoveries, 1)
+			_, _ = fmt.Fprintf(w, `{
+				"issuer": "https://sso.example.com/realms/realm",
+				"token_endpoint": "%[1]s/idp/token",
+				"introspection_endpoint": "%[1]s/idp/introspect",
+				"userinfo_endpoint": "%[1]s/idp/userinfo",
+				"end_session_endpoint": "%[1]s/idp/logout",
+				"revocation_endpoint": "%[1]s/idp/revoke",
+				"jwks_uri": "%[1]s/idp/certs",
+				"grant_types_supported": ["authorization_code", "client_credentials"]
+			}`, server.URL)
+		case "/idp/token":
+			_, _ = w.Write([]byte(`{"access_token":"access"}`))
+		case "/idp/introspect":
+			_, _ = w.Write([]byte(`{"active":true}`))
+		case "/idp/userinfo":
+			_, _ = w.Write([]byte(`{"sub":"f7c1"}`))
+		case "/idp/certs":
+			_, _ = w.Write([]byte(`{"keys":[]}`))
+		case "/idp/logout", "/idp/revoke":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+func TestGocloak_GetOpenIDConfiguration(t *testing.T) {
+	t.Parallel()
+	var discoveries int32
+	server := discoveryServer(t, &discoveries)
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	config, err := client.GetOpenIDConfiguration(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://sso.example.com/realms/realm", PString(config.Issuer))
+	assert.Equal(t, server.URL+"/idp/token", PString(config.TokenEndpoint))
+	assert.Equal(t, []string{"authorization_code", "client_credentials"}, *config.GrantTypesSupported)
+
+	// without discovery the default paths are used
+	_, err = client.LoginClient(context.Background(), "my-client", "secret", "realm")
+	assert.Error(t, err)
+}
+
+func TestGocloak_OpenIDDiscovery(t *testing.T) {
+	t.Parallel()
+	var discoveries int32
+	server := discoveryServer(t, &discoveries)
+	defer server.Close()
+	client := NewClient(server.URL, SetOpenIDDiscovery(true))
+	ctx := context.Background()
+
+	token, err := client.LoginClient(ctx, "my-client", "secret", "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken)
+
+	result, err := client.IntrospectToken(ctx, "my-client", "secret", "realm", "token", "")
+	assert.NoError(t, err)
+	assert.True(t, PBool(result.Active))
+
+	userInfo, err := client.GetUserInfo(ctx, "token", "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, "f7c1", PString(userInfo.Sub))
+
+	_, err = client.GetCerts(ctx, "realm")
+	assert.NoError(t, err)
+	assert.NoError(t, client.Logout(ctx, "my-client", "secret", "realm", "refresh"))
+	assert.NoError(t, client.RevokeToken(ctx, "my-client", "secret", "realm", "refresh", TokenTypeHintRefreshToken))
+
+	// the discovery document is requested once per realm
+	assert.EqualValues(t, 1, atomic.LoadInt32(&discoveries))
+
+	_, err = client.LoginClient(ctx, "my-client", "secret", "unknown")
+	assert.Error(t, err)
+}
@@ -1,22 +1,145 @@
 package gocloak
 
-// ObjectAlreadyExists is used when keycloak answers with 409
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// The errors the APIError matches with errors.Is
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	// ErrInvalidGrant is matched by the OAuth error invalid_grant,
+	// e.g. for wrong credentials or an expired refresh token
+	ErrInvalidGrant = errors.New("invalid grant")
+)
+
+// APIError is returned when keycloak answers with an error status
+type APIError struct {
+	// Code is the HTTP status code
+	Code int
+	// Message is the HTTP status followed by the keycloak error message
+	Message string
+	// Method and URL identify the failed request
+	Method string
+	URL    string
+	// ErrorCode is the error field of the response, the OAuth error code of the openid-connect endpoints
+	ErrorCode string
+	// ErrorMessage is the errorMessage field of the response, which is set by the admin REST API
+	ErrorMessage string
+	// ErrorDescription is the error_description field of the response
+	ErrorDescription string
+}
+
+// Error stringifies the APIError
+func (apiError APIError) Error() string {
+	return apiError.Message
+}
+
+// Is matches the APIError with the error of its status code or OAuth error code
+func (apiError APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return apiError.Code == http.StatusBadRequest
+	case ErrUnauthorized:
+		return apiError.Code == http.StatusUnauthorized
+	case ErrForbidden:
+		return apiError.Code == http.StatusForbidden
+	case ErrNotFound:
+		return apiError.Code == http.StatusNotFound
+	case ErrConflict:
+		return apiError.Code == http.StatusConflict
+	case ErrInvalidGrant:
+		return apiError.ErrorCode == "invalid_grant"
+	}
+	return false
+}
+
+// IsNotFound returns whether keycloak answered with 404
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsUnauthorized returns whether keycloak answered with 401
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsForbidden returns whether keycloak answered with 403
+func IsForbidden(err error) bool {
+	return errors.Is(err, ErrForbidden)
+}
+
+// IsConflict returns whether keycloak answered with 409
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// IsInvalidGrant returns whether keycloak answered with the OAuth error invalid_grant
+func IsInvalidGrant(err error) bool {
+	return errors.Is(err, ErrInvalidGrant)
+}
+
+// newAPIError creates the APIError of an error response
+func newAPIError(resp *resty.Response) *APIError {
+	apiError := &APIError{
+		Code:    resp.StatusCode(),
+		Message: resp.Status(),
+	}
+	if resp.Request != nil {
+		apiError.Method = resp.Request.Method
+		apiError.URL = resp.Request.URL
+	}
+	if e, ok := resp.Error().(*HTTPErrorResponse); ok && e != nil {
+		apiError.ErrorCode = e.Error
+		apiError.ErrorMessage = e.ErrorMessage
+		apiError.ErrorDescription = e.ErrorDescription
+	}
+
+	if len(apiError.ErrorMessage) > 0 {
+		apiError.Message = fmt.Sprintf("%s: %s", resp.Status(), apiError.ErrorMessage)
+	} else if len(apiError.ErrorCode) > 0 && len(apiError.ErrorDescription) > 0 {
+		apiError.Message = fmt.Sprintf("%s: %s: %s", resp.Status(), apiError.ErrorCode, apiError.ErrorDescription)
+	} else if len(apiError.ErrorCode) > 0 {
+		apiError.Message = fmt.Sprintf("%s: %s", resp.Status(), apiError.ErrorCode)
+	}
+	return apiError
+}
+
+// ObjectAlreadyExists is used when keycloak answers with 409.
+// It wraps the APIError, so it matches ErrConflict as well
 type ObjectAlreadyExists struct {
 	ErrorMessage string
+	apiError     *APIError
 }
 
 func (e *ObjectAlreadyExists) Error() string {
 	return e.ErrorMessage
 }
 
+// Unwrap returns the APIError of the response
+func (e *ObjectAlreadyExists) Unwrap() error {
+	if e.apiError == nil {
+		return nil
+	}
+	return e.apiError
+}
+
 // IsObjectAlreadyExists is a helper to verify tht the err is ObjectAlreadyExists
 func IsObjectAlreadyExists(err error) bool {
-	_, ok := err.(*ObjectAlreadyExists)
-	return ok
+	var e *ObjectAlreadyExists
+	return errors.As(err, &e)
 }
 
 // HTTPErrorResponse is a model of an error response
 type HTTPErrorResponse struct {
-	ErrorMessage string `json:"errorMessage,omitempty"`
-	Error        string `json:"error,omitempty"`
+	ErrorMessage     string `json:"errorMessage,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
 }
@@ -0,0 +1,72 @@
+package gocloak
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func errorServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/auth/admin/realms/realm/groups" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		switch r.URL.Path {
+		case "/auth/realms/realm/protocol/openid-connect/token":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"Invalid user credentials"}`))
+		case "/auth/admin/realms/realm/users":
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"errorMessage":"User exists with same username"}`))
+		case "/auth/admin/realms/realm/groups":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"User not found"}`))
+		}
+	}))
+}
+
+func TestAPIError(t *testing.T) {
+	t.Parallel()
+	server := errorServer()
+	defer server.Close()
+	client := NewClient(server.URL)
+	ctx := context.Background()
+
+	_, err := client.Login(ctx, "my-client", "secret", "realm", "user", "wrong")
+	var apiError *APIError
+	assert.True(t, errors.As(err, &apiError))
+	assert.Equal(t, http.StatusBadRequest, apiError.Code)
+	assert.Equal(t, http.MethodPost, apiError.Method)
+	assert.Equal(t, server.URL+"/auth/realms/realm/protocol/openid-connect/token", apiError.URL)
+	assert.Equal(t, "invalid_grant", apiError.ErrorCode)
+	assert.Equal(t, "Invalid user credentials", apiError.ErrorDescription)
+	assert.Equal(t, "400 Bad Request: invalid_grant: Invalid user credentials", err.Error())
+	assert.True(t, IsInvalidGrant(err))
+	assert.True(t, errors.Is(err, ErrBadRequest))
+	assert.False(t, IsUnauthorized(err))
+
+	_, err = client.GetUserByID(ctx, "token", "realm", "f7c1")
+	assert.True(t, IsNotFound(err))
+	assert.False(t, IsInvalidGrant(err))
+	assert.Equal(t, "404 Not Found: User not found", err.Error())
+
+	_, err = client.GetGroups(ctx, "token", "realm", GetGroupsParams{})
+	assert.True(t, IsForbidden(err))
+	assert.Equal(t, "403 Forbidden", err.Error())
+
+	_, err = client.CreateUser(ctx, "token", "realm", User{Username: StringP("jdoe")})
+	assert.True(t, IsObjectAlreadyExists(err))
+	assert.True(t, IsConflict(err))
+	assert.True(t, errors.As(err, &apiError))
+	assert.Equal(t, "User exists with same username", apiError.ErrorMessage)
+	assert.Equal(t, "409 Conflict: User exists with same username", err.Error())
+
+	assert.False(t, IsNotFound(errors.New("404 Not Found")))
+	assert.False(t, IsNotFound(nil))
+}
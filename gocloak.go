@@ -1,136 +1,190 @@
 package gocloak
 
 import (
+	"context"
+
 	"github.com/dgrijalva/jwt-go"
 	"github.com/go-resty/resty/v2"
+	"github.com/kkovarik/gocloak/pkg/jwx"
 )
 
 // GoCloak holds all methods a client should fulfill
 type GoCloak interface {
-	// RestyClient returns a resty client that gocloak uses
+	// RestyClient returns a resty client that gocloak uses. Its pre-request hook starts the instrumentations
 	RestyClient() *resty.Client
-	// Sets the resty Client that gocloak uses
+	// SetRestyClient sets the resty client that gocloak uses. The options of NewClient are applied to it,
+	// e.g. the retry policy, the rate limits and the instrumentations
 	SetRestyClient(restyClient *resty.Client)
 
 	// GetToken returns a token
-	GetToken(realm string, options TokenOptions) (*JWT, error)
+	GetToken(ctx context.Context, realm string, options TokenOptions) (*JWT, error)
 	// Login sends a request to the token endpoint using user and client credentials
-	Login(clientID, clientSecret, realm, username, password string) (*JWT, error)
+	Login(ctx context.Context, clientID, clientSecret, realm, username, password string) (*JWT, error)
+	// GetAuthCodeURL returns the URL of the authorization endpoint of the authorization code flow
+	GetAuthCodeURL(realm string, options AuthCodeURLOptions) (string, error)
+	// ExchangeCode exchanges the authorization code for a token and checks the nonce of the ID token
+	ExchangeCode(ctx context.Context, realm string, options TokenOptions, nonce string) (*JWT, error)
+	// StartDeviceAuthorization starts the device authorization grant and returns the user code and the verification URI
+	StartDeviceAuthorization(ctx context.Context, clientID, clientSecret, realm string, scopes ...string) (*DeviceAuthorization, error)
+	// WaitForDeviceToken polls the token endpoint until the user has approved the device authorization
+	WaitForDeviceToken(ctx context.Context, clientID, clientSecret, realm string, authorization *DeviceAuthorization) (*JWT, error)
+	// ExchangeToken exchanges a token for a token of another client, user or identity provider
+	ExchangeToken(ctx context.Context, realm string, options TokenOptions) (*JWT, error)
+	// CreatePermissionTicket creates a permission ticket using the protection API token of a resource server
+	CreatePermissionTicket(ctx context.Context, token, realm string, permissions []CreatePermissionTicketParams) (*PermissionTicketResponseRepresentation, error)
+	// GetRequestingPartyToken obtains or upgrades a requesting party token with the uma-ticket grant
+	GetRequestingPartyToken(ctx context.Context, token, realm string, options RequestingPartyTokenOptions) (*JWT, error)
+	// GetRequestingPartyPermissions returns the permissions the uma-ticket grant would grant
+	GetRequestingPartyPermissions(ctx context.Context, token, realm string, options RequestingPartyTokenOptions) (*[]RequestingPartyPermission, error)
+	// GetRequestingPartyPermissionDecision returns whether the uma-ticket grant would grant the permissions
+	GetRequestingPartyPermissionDecision(ctx context.Context, token, realm string, options RequestingPartyTokenOptions) (*RequestingPartyPermissionDecision, error)
 	// Logout sends a request to the logout endpoint using refresh token
-	Logout(clientID, clientSecret, realm, refreshToken string) error
+	Logout(ctx context.Context, clientID, clientSecret, realm, refreshToken string) error
 	// LogoutPublicClient sends a request to the logout endpoint using refresh token
-	LogoutPublicClient(clientID, realm, accessToken, refreshToken string) error
+	LogoutPublicClient(ctx context.Context, clientID, realm, accessToken, refreshToken string) error
+	// RevokeToken revokes a token at the revocation endpoint
+	RevokeToken(ctx context.Context, clientID, clientSecret, realm, token, tokenTypeHint string) error
 	// LoginClient sends a request to the token endpoint using client credentials
-	LoginClient(clientID, clientSecret, realm string) (*JWT, error)
+	LoginClient(ctx context.Context, clientID, clientSecret, realm string) (*JWT, error)
 	// LoginAdmin login as admin
-	LoginAdmin(username, password, realm string) (*JWT, error)
+	LoginAdmin(ctx context.Context, username, password, realm string) (*JWT, error)
 	// RequestPermission sends a request to the token endpoint with permission parameter
-	RequestPermission(clientID, clientSecret, realm, username, password, permission string) (*JWT, error)
+	RequestPermission(ctx context.Context, clientID, clientSecret, realm, username, password, permission string) (*JWT, error)
 	// RefreshToken used to refresh the token
-	RefreshToken(refreshToken string, clientID, clientSecret, realm string) (*JWT, error)
-	// DecodeAccessToken decodes the accessToken
-	DecodeAccessToken(accessToken string, realm string) (*jwt.Token, *jwt.MapClaims, error)
-	// DecodeAccessTokenCustomClaims decodes the accessToken and fills the given claims
-	DecodeAccessTokenCustomClaims(accessToken string, realm string, claims jwt.Claims) (*jwt.Token, error)
+	RefreshToken(ctx context.Context, refreshToken string, clientID, clientSecret, realm string) (*JWT, error)
+	// DecodeAccessToken decodes the accessToken and validates its claims with the given options
+	DecodeAccessToken(ctx context.Context, accessToken string, realm string, options ...jwx.ValidationOption) (*jwt.Token, *jwt.MapClaims, error)
+	// DecodeAccessTokenCustomClaims decodes the accessToken, validates its claims with the given options and fills the given claims
+	DecodeAccessTokenCustomClaims(ctx context.Context, accessToken string, realm string, claims jwt.Claims, options ...jwx.ValidationOption) (*jwt.Token, error)
+	// SetClientAuthenticator sets how the client of the realm authenticates, e.g. with private_key_jwt
+	SetClientAuthenticator(realm, clientID string, authenticator ClientAuthenticator)
+	// SetRealmSecret sets the secret used to verify HMAC signed tokens of the given realm
+	SetRealmSecret(realm string, secret []byte)
 	// DecodeAccessTokenCustomClaims calls the token introspection endpoint
-	RetrospectToken(accessToken string, clientID, clientSecret string, realm string) (*RetrospecTokenResult, error)
+	RetrospectToken(ctx context.Context, accessToken string, clientID, clientSecret string, realm string) (*RetrospecTokenResult, error)
+	// IntrospectToken calls the introspect endpoint with the given token type hint
+	IntrospectToken(ctx context.Context, clientID, clientSecret, realm, token, tokenTypeHint string) (*RetrospecTokenResult, error)
+	// IntrospectTokenCustomClaims calls the introspect endpoint and decodes the response into the claims
+	IntrospectTokenCustomClaims(ctx context.Context, clientID, clientSecret, realm, token, tokenTypeHint string, claims interface{}) error
 	// GetIssuer calls the issuer endpoint for the given realm
-	GetIssuer(realm string) (*IssuerResponse, error)
+	GetIssuer(ctx context.Context, realm string) (*IssuerResponse, error)
+	// GetOpenIDConfiguration returns the discovery document of the realm
+	GetOpenIDConfiguration(ctx context.Context, realm string) (*OpenIDConfiguration, error)
 	// GetCerts gets the public keys for the given realm
-	GetCerts(realm string) (*CertResponse, error)
+	GetCerts(ctx context.Context, realm string) (*CertResponse, error)
+	// SetCerts seeds the certs cache of the realm with the given JWKS document
+	SetCerts(realm string, certs *CertResponse)
+	// SetRealmPublicKey seeds the certs cache of the realm with a PEM or base64 DER encoded public key
+	SetRealmPublicKey(realm string, publicKey string) error
+	// GetCertsCacheStats returns the counters of the certs cache
+	GetCertsCacheStats() CertsCacheStats
+	// Close stops the background prefetching of the certs and cancels the requests of the certs in flight
+	Close()
+	// GetIntrospectionCacheStats returns the counters of the introspection cache
+	GetIntrospectionCacheStats() IntrospectionCacheStats
 	// GetServerInfo returns the server info
-	GetServerInfo(accessToken string) (*ServerInfoRepesentation, error)
+	GetServerInfo(ctx context.Context, accessToken string) (*ServerInfoRepesentation, error)
 	// GetUserInfo gets the user info for the given realm
-	GetUserInfo(accessToken string, realm string) (*UserInfo, error)
+	GetUserInfo(ctx context.Context, accessToken string, realm string) (*UserInfo, error)
 
 	// ExecuteActionsEmail executes an actions email
-	ExecuteActionsEmail(token string, realm string, params ExecuteActionsEmail) error
+	ExecuteActionsEmail(ctx context.Context, token string, realm string, params ExecuteActionsEmail) error
 
 	// CreateGroup creates a new group
-	CreateGroup(accessToken, realm string, group Group) (string, error)
+	CreateGroup(ctx context.Context, accessToken, realm string, group Group) (string, error)
 	// CreateChildGroup creates a new child group
-	CreateChildGroup(token string, realm string, groupID string, group Group) (string, error)
+	CreateChildGroup(ctx context.Context, token string, realm string, groupID string, group Group) (string, error)
 	// CreateClient creates a new client
-	CreateClient(accessToken, realm string, clientID Client) (string, error)
+	CreateClient(ctx context.Context, accessToken, realm string, clientID Client) (string, error)
 	// CreateClientScope creates a new clientScope
-	CreateClientScope(accessToken, realm string, scope ClientScope) (string, error)
+	CreateClientScope(ctx context.Context, accessToken, realm string, scope ClientScope) (string, error)
 	// CreateComponent creates a new component
-	CreateComponent(accessToken, realm string, component Component) (string, error)
+	CreateComponent(ctx context.Context, accessToken, realm string, component Component) (string, error)
 
 	// UpdateGroup updates the given group
-	UpdateGroup(accessToken string, realm string, updatedGroup Group) error
+	UpdateGroup(ctx context.Context, accessToken string, realm string, updatedGroup Group) error
 	// UpdateRole updates the given role
-	UpdateRole(accessToken string, realm string, clientID string, role Role) error
+	UpdateRole(ctx context.Context, accessToken string, realm string, clientID string, role Role) error
 	// UpdateClient updates the given client
-	UpdateClient(accessToken string, realm string, updatedClient Client) error
+	UpdateClient(ctx context.Context, accessToken string, realm string, updatedClient Client) error
 	// UpdateClientScope updates the given clientScope
-	UpdateClientScope(accessToken string, realm string, scope ClientScope) error
+	UpdateClientScope(ctx context.Context, accessToken string, realm string, scope ClientScope) error
 
 	// DeleteComponent deletes the given component
-	DeleteComponent(accessToken string, realm, componentID string) error
+	DeleteComponent(ctx context.Context, accessToken string, realm, componentID string) error
 	// DeleteGroup deletes the given group
-	DeleteGroup(accessToken string, realm, groupID string) error
+	DeleteGroup(ctx context.Context, accessToken string, realm, groupID string) error
 	// DeleteClient deletes the given client
-	DeleteClient(accessToken string, realm, clientID string) error
+	DeleteClient(ctx context.Context, accessToken string, realm, clientID string) error
 	// DeleteClientScope
-	DeleteClientScope(accessToken string, realm, scopeID string) error
+	DeleteClientScope(ctx context.Context, accessToken string, realm, scopeID string) error
 
 	// GetClient returns a client
-	GetClient(accessToken string, realm string, clientID string) (*Client, error)
+	GetClient(ctx context.Context, accessToken string, realm string, clientID string) (*Client, error)
 	// GetClientsDefaultScopes returns a list of the client's default scopes
-	GetClientsDefaultScopes(token string, realm string, clientID string) ([]*ClientScope, error)
+	GetClientsDefaultScopes(ctx context.Context, token string, realm string, clientID string) ([]*ClientScope, error)
 	// AddDefaultScopeToClient adds a client scope to the list of client's default scopes
-	AddDefaultScopeToClient(token string, realm string, clientID string, scopeID string) error
+	AddDefaultScopeToClient(ctx context.Context, token string, realm string, clientID string, scopeID string) error
 	// RemoveDefaultScopeFromClient removes a client scope from the list of client's default scopes
-	RemoveDefaultScopeFromClient(token string, realm string, clientID string, scopeID string) error
+	RemoveDefaultScopeFromClient(ctx context.Context, token string, realm string, clientID string, scopeID string) error
 	// GetClientsOptionalScopes returns a list of the client's optional scopes
-	GetClientsOptionalScopes(token string, realm string, clientID string) ([]*ClientScope, error)
+	GetClientsOptionalScopes(ctx context.Context, token string, realm string, clientID string) ([]*ClientScope, error)
 	// AddOptionalScopeToClient adds a client scope to the list of client's optional scopes
-	AddOptionalScopeToClient(token string, realm string, clientID string, scopeID string) error
+	AddOptionalScopeToClient(ctx context.Context, token string, realm string, clientID string, scopeID string) error
 	// RemoveOptionalScopeFromClient deletes a client scope from the list of client's optional scopes
-	RemoveOptionalScopeFromClient(token string, realm string, clientID string, scopeID string) error
+	RemoveOptionalScopeFromClient(ctx context.Context, token string, realm string, clientID string, scopeID string) error
 	// GetDefaultOptionalClientScopes returns a list of default realm optional scopes
-	GetDefaultOptionalClientScopes(token string, realm string) ([]*ClientScope, error)
+	GetDefaultOptionalClientScopes(ctx context.Context, token string, realm string) ([]*ClientScope, error)
 	// GetDefaultDefaultClientScopes returns a list of default realm default scopes
-	GetDefaultDefaultClientScopes(token string, realm string) ([]*ClientScope, error)
+	GetDefaultDefaultClientScopes(ctx context.Context, token string, realm string) ([]*ClientScope, error)
 	// GetClientScope returns a clientscope
-	GetClientScope(token string, realm string, scopeID string) (*ClientScope, error)
+	GetClientScope(ctx context.Context, token string, realm string, scopeID string) (*ClientScope, error)
 	// GetClientScopes returns all client scopes
-	GetClientScopes(token string, realm string) ([]*ClientScope, error)
+	GetClientScopes(ctx context.Context, token string, realm string) ([]*ClientScope, error)
 	// GetClientScopeMappingClientRoles does return list of clients roles in specific Client Scope mapping
-	GetClientScopeMappingClientRoles(token string, realm string, scopeID string, clientID string) ([]*Role, error)
+	GetClientScopeMappingClientRoles(ctx context.Context, token string, realm string, scopeID string, clientID string) ([]*Role, error)
 	// AddClientScopeMappingClientRole does add clients roles to specific Client Scope mapping
-	AddClientScopeMappingClientRoles(token string, realm string, scopeID string, clientID string, roles []*Role) error
+	AddClientScopeMappingClientRoles(ctx context.Context, token string, realm string, scopeID string, clientID string, roles []*Role) error
 	// GetClientSecret returns a client's secret
-	GetClientSecret(token string, realm string, clientID string) (*CredentialRepresentation, error)
+	GetClientSecret(ctx context.Context, token string, realm string, clientID string) (*CredentialRepresentation, error)
 	// GetClientServiceAccount retrieves the service account "user" for a client if enabled
-	GetClientServiceAccount(token string, realm string, clientID string) (*User, error)
+	GetClientServiceAccount(ctx context.Context, token string, realm string, clientID string) (*User, error)
 	// RegenerateClientSecret creates a new client secret returning the updated CredentialRepresentation
-	RegenerateClientSecret(token string, realm string, clientID string) (*CredentialRepresentation, error)
+	RegenerateClientSecret(ctx context.Context, token string, realm string, clientID string) (*CredentialRepresentation, error)
 	// GetKeyStoreConfig gets the keyStoreConfig
-	GetKeyStoreConfig(accessToken string, realm string) (*KeyStoreConfig, error)
+	GetKeyStoreConfig(ctx context.Context, accessToken string, realm string) (*KeyStoreConfig, error)
 	// GetComponents gets components of the given realm
-	GetComponents(accessToken string, realm string) ([]*Component, error)
+	GetComponents(ctx context.Context, accessToken string, realm string) ([]*Component, error)
 	// GetGroups gets all groups of the given realm
-	GetGroups(accessToken string, realm string, params GetGroupsParams) ([]*Group, error)
+	GetGroups(ctx context.Context, accessToken string, realm string, params GetGroupsParams) ([]*Group, error)
+	// IterateGroups returns an iterator over the groups of the given realm, which requests them page by page
+	IterateGroups(ctx context.Context, accessToken string, realm string, params GetGroupsParams) *GroupIterator
 	// GetGroup gets the given group
-	GetGroup(accessToken string, realm, groupID string) (*Group, error)
+	GetGroup(ctx context.Context, accessToken string, realm, groupID string) (*Group, error)
 	// GetGroupMembers get a list of users of group with id in realm
-	GetGroupMembers(accessToken string, realm, groupID string, params GetGroupsParams) ([]*User, error)
+	GetGroupMembers(ctx context.Context, accessToken string, realm, groupID string, params GetGroupsParams) ([]*User, error)
+	// IterateGroupMembers returns an iterator over the members of the group, which requests them page by page
+	IterateGroupMembers(ctx context.Context, accessToken string, realm, groupID string, params GetGroupsParams) *UserIterator
 	// GetRoleMappingByGroupID gets the rolemapping for the given group id
-	GetRoleMappingByGroupID(accessToken string, realm string, groupID string) (*MappingsRepresentation, error)
+	GetRoleMappingByGroupID(ctx context.Context, accessToken string, realm string, groupID string) (*MappingsRepresentation, error)
 	// GetRoleMappingByUserID gets the rolemapping for the given user id
-	GetRoleMappingByUserID(accessToken string, realm string, userID string) (*MappingsRepresentation, error)
+	GetRoleMappingByUserID(ctx context.Context, accessToken string, realm string, userID string) (*MappingsRepresentation, error)
 	// GetClients gets the clients in the realm
-	GetClients(accessToken string, realm string, params GetClientsParams) ([]*Client, error)
+	GetClients(ctx context.Context, accessToken string, realm string, params GetClientsParams) ([]*Client, error)
+	// IterateClients returns an iterator over the clients of the given realm, which requests them page by page
+	IterateClients(ctx context.Context, accessToken string, realm string, params GetClientsParams) *ClientIterator
 	// GetClientOfflineSessions returns offline sessions associated with the client
-	GetClientOfflineSessions(token, realm, clientID string) ([]*UserSessionRepresentation, error)
+	GetClientOfflineSessions(ctx context.Context, token, realm, clientID string, params ...GetClientUserSessionsParams) ([]*UserSessionRepresentation, error)
+	// IterateClientOfflineSessions returns an iterator over the offline sessions of the client, which requests them page by page
+	IterateClientOfflineSessions(ctx context.Context, token, realm, clientID string, params GetClientUserSessionsParams) *UserSessionIterator
 	// GetClientUserSessions returns user sessions associated with the client
-	GetClientUserSessions(token, realm, clientID string) ([]*UserSessionRepresentation, error)
+	GetClientUserSessions(ctx context.Context, token, realm, clientID string, params ...GetClientUserSessionsParams) ([]*UserSessionRepresentation, error)
+	// IterateClientUserSessions returns an iterator over the user sessions of the client, which requests them page by page
+	IterateClientUserSessions(ctx context.Context, token, realm, clientID string, params GetClientUserSessionsParams) *UserSessionIterator
 	// CreateClientProtocolMapper creates a protocol mapper in client scope
-	CreateClientProtocolMapper(token, realm, clientID string, mapper ProtocolMapperRepresentation) (string, error)
+	CreateClientProtocolMapper(ctx context.Context, token, realm, clientID string, mapper ProtocolMapperRepresentation) (string, error)
 	// DeleteClientProtocolMapper deletes a protocol mapper in client scope
-	DeleteClientProtocolMapper(token, realm, clientID, mapperID string) error
+	DeleteClientProtocolMapper(ctx context.Context, token, realm, clientID, mapperID string) error
 
 	// UserAttributeContains checks if the given attribute has the given value
 	UserAttributeContains(attributes map[string][]string, attribute string, value string) bool
@@ -138,83 +192,103 @@ type GoCloak interface {
 	// *** Realm Roles ***
 
 	// CreateRealmRole creates a role in a realm
-	CreateRealmRole(token, realm string, role Role) (string, error)
+	CreateRealmRole(ctx context.Context, token, realm string, role Role) (string, error)
 	// GetRealmRole returns a role from a realm by role's name
-	GetRealmRole(token string, realm string, roleName string) (*Role, error)
+	GetRealmRole(ctx context.Context, token string, realm string, roleName string) (*Role, error)
 	// GetRealmRoles get all roles of the given realm. It's an alias for the GetRoles function
-	GetRealmRoles(accessToken string, realm string) ([]*Role, error)
+	GetRealmRoles(ctx context.Context, accessToken string, realm string, params ...GetRoleParams) ([]*Role, error)
+	// IterateRealmRoles returns an iterator over the roles of the given realm, which requests them page by page
+	IterateRealmRoles(ctx context.Context, accessToken string, realm string, params GetRoleParams) *RoleIterator
 	// GetRealmRolesByUserID returns all roles assigned to the given user
-	GetRealmRolesByUserID(accessToken string, realm string, userID string) ([]*Role, error)
+	GetRealmRolesByUserID(ctx context.Context, accessToken string, realm string, userID string) ([]*Role, error)
 	// GetRealmRolesByGroupID returns all roles assigned to the given group
-	GetRealmRolesByGroupID(accessToken string, realm string, groupID string) ([]*Role, error)
+	GetRealmRolesByGroupID(ctx context.Context, accessToken string, realm string, groupID string) ([]*Role, error)
 	// UpdateRealmRole updates a role in a realm
-	UpdateRealmRole(token string, realm string, roleName string, role Role) error
+	UpdateRealmRole(ctx context.Context, token string, realm string, roleName string, role Role) error
 	// DeleteRealmRole deletes a role in a realm by role's name
-	DeleteRealmRole(token string, realm string, roleName string) error
+	DeleteRealmRole(ctx context.Context, token string, realm string, roleName string) error
 	// AddRealmRoleToUser adds realm-level role mappings
-	AddRealmRoleToUser(token string, realm string, userID string, roles []Role) error
+	AddRealmRoleToUser(ctx context.Context, token string, realm string, userID string, roles []Role) error
 	// DeleteRealmRoleFromUser deletes realm-level role mappings
-	DeleteRealmRoleFromUser(token string, realm string, userID string, roles []Role) error
+	DeleteRealmRoleFromUser(ctx context.Context, token string, realm string, userID string, roles []Role) error
 	// AddRealmRoleComposite adds roles as composite
-	AddRealmRoleComposite(token string, realm string, roleName string, roles []Role) error
+	AddRealmRoleComposite(ctx context.Context, token string, realm string, roleName string, roles []Role) error
 	// AddRealmRoleComposite adds roles as composite
-	DeleteRealmRoleComposite(token string, realm string, roleName string, roles []Role) error
+	DeleteRealmRoleComposite(ctx context.Context, token string, realm string, roleName string, roles []Role) error
 
 	// *** Client Roles ***
 
 	// AddClientRoleToUser adds a client role to the user
-	AddClientRoleToUser(token string, realm string, clientID string, userID string, roles []Role) error
+	AddClientRoleToUser(ctx context.Context, token string, realm string, clientID string, userID string, roles []Role) error
 	// AddClientRoleToGroup adds a client role to the group
-	AddClientRoleToGroup(token string, realm string, clientID string, groupID string, roles []Role) error
+	AddClientRoleToGroup(ctx context.Context, token string, realm string, clientID string, groupID string, roles []Role) error
 	// CreateClientRole creates a new role for a client
-	CreateClientRole(accessToken, realm, clientID string, role Role) (string, error)
+	CreateClientRole(ctx context.Context, accessToken, realm, clientID string, role Role) (string, error)
 	// DeleteClientRole deletes the given role
-	DeleteClientRole(accessToken, realm, clientID, roleName string) error
+	DeleteClientRole(ctx context.Context, accessToken, realm, clientID, roleName string) error
 	// DeleteClientRoleFromUser removes a client role from from the user
-	DeleteClientRoleFromUser(token string, realm string, clientID string, userID string, roles []Role) error
+	DeleteClientRoleFromUser(ctx context.Context, token string, realm string, clientID string, userID string, roles []Role) error
 	// GetClientRoles gets roles for the given client
-	GetClientRoles(accessToken string, realm string, clientID string) ([]*Role, error)
+	GetClientRoles(ctx context.Context, accessToken string, realm string, clientID string, params ...GetRoleParams) ([]*Role, error)
+	// IterateClientRoles returns an iterator over the roles of the given client, which requests them page by page
+	IterateClientRoles(ctx context.Context, accessToken string, realm string, clientID string, params GetRoleParams) *RoleIterator
 	// GetClientRole get a role for the given client in a realm by role name
-	GetClientRole(token string, realm string, clientID string, roleName string) (*Role, error)
+	GetClientRole(ctx context.Context, token string, realm string, clientID string, roleName string) (*Role, error)
 
 	// *** Realm ***
 
 	// GetRealm returns top-level representation of the realm
-	GetRealm(token string, realm string) (*RealmRepresentation, error)
+	GetRealm(ctx context.Context, token string, realm string) (*RealmRepresentation, error)
 	// GetRealms returns top-level representation of all realms
-	GetRealms(token string) ([]*RealmRepresentation, error)
+	GetRealms(ctx context.Context, token string) ([]*RealmRepresentation, error)
 	// CreateRealm creates a realm
-	CreateRealm(token string, realm RealmRepresentation) (string, error)
+	CreateRealm(ctx context.Context, token string, realm RealmRepresentation) (string, error)
 	// DeleteRealm removes a realm
-	DeleteRealm(token string, realm string) error
+	DeleteRealm(ctx context.Context, token string, realm string) error
 	// ClearRealmCache clears realm cache
-	ClearRealmCache(token string, realm string) error
+	ClearRealmCache(ctx context.Context, token string, realm string) error
 
 	// *** Users ***
 	// CreateUser creates a new user
-	CreateUser(token string, realm string, user User) (string, error)
+	CreateUser(ctx context.Context, token string, realm string, user User) (string, error)
 	// DeleteUser deletes the given user
-	DeleteUser(accessToken string, realm, userID string) error
+	DeleteUser(ctx context.Context, accessToken string, realm, userID string) error
 	// GetUserByID gets the user with the given id
-	GetUserByID(accessToken string, realm string, userID string) (*User, error)
+	GetUserByID(ctx context.Context, accessToken string, realm string, userID string) (*User, error)
 	// GetUser count returns the userCount of the given realm
-	GetUserCount(accessToken string, realm string) (int, error)
+	GetUserCount(ctx context.Context, accessToken string, realm string) (int, error)
 	// GetUsers gets all users of the given realm
-	GetUsers(accessToken string, realm string, params GetUsersParams) ([]*User, error)
+	GetUsers(ctx context.Context, accessToken string, realm string, params GetUsersParams) ([]*User, error)
+	// IterateUsers returns an iterator over the users of the given realm, which requests them page by page
+	IterateUsers(ctx context.Context, accessToken string, realm string, params GetUsersParams) *UserIterator
 	// GetUserGroups gets the groups of the given user
-	GetUserGroups(accessToken string, realm string, userID string) ([]*UserGroup, error)
+	GetUserGroups(ctx context.Context, accessToken string, realm string, userID string, params ...GetUserGroupsParams) ([]*UserGroup, error)
+	// IterateUserGroups returns an iterator over the groups of the given user, which requests them page by page
+	IterateUserGroups(ctx context.Context, accessToken string, realm string, userID string, params GetUserGroupsParams) *UserGroupIterator
 	// GetUsersByRoleName returns all users have a given role
-	GetUsersByRoleName(token string, realm string, roleName string) ([]*User, error)
+	GetUsersByRoleName(ctx context.Context, token string, realm string, roleName string, params ...GetUsersByRoleParams) ([]*User, error)
+	// IterateUsersByRoleName returns an iterator over the users with the given role, which requests them page by page
+	IterateUsersByRoleName(ctx context.Context, token string, realm string, roleName string, params GetUsersByRoleParams) *UserIterator
 	// SetPassword sets a new password for the user with the given id. Needs elevated privileges
-	SetPassword(token string, userID string, realm string, password string, temporary bool) error
+	SetPassword(ctx context.Context, token string, userID string, realm string, password string, temporary bool) error
 	// UpdateUser updates the given user
-	UpdateUser(accessToken string, realm string, user User) error
+	UpdateUser(ctx context.Context, accessToken string, realm string, user User) error
 	// AddUserToGroup puts given user to given group
-	AddUserToGroup(token string, realm string, userID string, groupID string) error
+	AddUserToGroup(ctx context.Context, token string, realm string, userID string, groupID string) error
 	// DeleteUserFromGroup deletes given user from given group
-	DeleteUserFromGroup(token string, realm string, userID string, groupID string) error
+	DeleteUserFromGroup(ctx context.Context, token string, realm string, userID string, groupID string) error
 	// GetUserSessions returns user sessions associated with the user
-	GetUserSessions(token, realm, userID string) ([]*UserSessionRepresentation, error)
+	GetUserSessions(ctx context.Context, token, realm, userID string) ([]*UserSessionRepresentation, error)
+	// LogoutAllSessions logs out all sessions of the user
+	LogoutAllSessions(ctx context.Context, token, realm, userID string) error
+	// LogoutUserSession logs out a single session by its ID
+	LogoutUserSession(ctx context.Context, token, realm, session string) error
+	// LogoutAllRealmSessions logs out all sessions of the realm
+	LogoutAllRealmSessions(ctx context.Context, token, realm string) (*GlobalRequestResult, error)
+	// PushRealmRevocation pushes the not-before revocation policy of the realm
+	PushRealmRevocation(ctx context.Context, token, realm string) (*GlobalRequestResult, error)
+	// PushClientRevocation pushes the not-before revocation policy of the client
+	PushClientRevocation(ctx context.Context, token, realm, clientID string) (*GlobalRequestResult, error)
 	// GetUserOfflineSessionsForClient returns offline sessions associated with the user and client
-	GetUserOfflineSessionsForClient(token, realm, userID, clientID string) ([]*UserSessionRepresentation, error)
+	GetUserOfflineSessionsForClient(ctx context.Context, token, realm, userID, clientID string) ([]*UserSessionRepresentation, error)
 }
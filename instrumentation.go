@@ -0,0 +1,141 @@
+package gocloak
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Instrumentation is invoked around every request to keycloak, e.g. to record metrics or traces
+type Instrumentation interface {
+	// StartRequest is called before the request of the operation is sent.
+	// The returned context is used for the request and passed to EndRequest
+	StartRequest(ctx context.Context, operation string) context.Context
+	// EndRequest is called once the request has finished, including its retries
+	EndRequest(ctx context.Context, info RequestInfo)
+}
+
+// RequestInfo describes a finished request
+type RequestInfo struct {
+	// Operation is the name of the client method which sent the request, e.g. "GetUsers"
+	Operation string
+	// Realm is the realm of the request, empty for requests which do not belong to a realm
+	Realm  string
+	Method string
+	// URL is the URL of the request without its query
+	URL string
+	// StatusCode is the status code of the response, zero if the request failed without a response
+	StatusCode int
+	// Latency is the time from sending the request until its last response, including the retries
+	Latency time.Duration
+	// Retries is the number of retries after the first attempt
+	Retries int
+	// Err is the error returned to the caller
+	Err error
+}
+
+// SetInstrumentation adds an instrumentation which is invoked around every request.
+// Several instrumentations are invoked in the order they are added
+func SetInstrumentation(instrumentation Instrumentation) ClientOption {
+	return func(client *gocloak) {
+		client.instrumentations = append(client.instrumentations, instrumentation)
+	}
+}
+
+type requestStateKey struct{}
+
+// requestState is the state of an instrumented request
+type requestState struct {
+	instrumentations []Instrumentation
+	operation        string
+	realm            string
+	// ctx is the context returned by the instrumentations, set once the first attempt is sent
+	ctx   context.Context
+	start time.Time
+	once  sync.Once
+}
+
+// withRequestState adds the state of a new request of the operation in the realm to the context.
+// The instrumentations are only invoked once the request is sent
+func (client *gocloak) withRequestState(ctx context.Context, operation, realm string) context.Context {
+	if len(client.instrumentations) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, requestStateKey{}, &requestState{
+		instrumentations: client.instrumentations,
+		operation:        operation,
+		realm:            realm,
+	})
+}
+
+func getRequestState(ctx context.Context) *requestState {
+	if ctx == nil {
+		return nil
+	}
+	state, _ := ctx.Value(requestStateKey{}).(*requestState)
+	return state
+}
+
+// instrumentRequests starts the instrumentations in the pre-request hook, which runs after the middlewares.
+// Requests which fail before they are sent, e.g. because the client authentication failed, are not instrumented.
+// A sent request is ended by checkForError with its response, or by the middleware failing a retry
+func (client *gocloak) instrumentRequests() {
+	if len(client.instrumentations) == 0 {
+		return
+	}
+	client.restyClient.SetPreRequestHook(func(_ *resty.Client, req *http.Request) error {
+		state := getRequestState(req.Context())
+		if state == nil {
+			return nil
+		}
+		if state.ctx == nil {
+			state.start = time.Now()
+			ctx := req.Context()
+			for _, instrumentation := range state.instrumentations {
+				ctx = instrumentation.StartRequest(ctx, state.operation)
+			}
+			state.ctx = ctx
+		}
+		// every attempt is sent with the context of the instrumentations
+		*req = *req.WithContext(state.ctx)
+		return nil
+	})
+}
+
+// endRequest invokes the instrumentations once the request has finished.
+// The response is nil if a retry failed in a middleware, before it was sent
+func endRequest(req *resty.Request, resp *resty.Response, err error) {
+	if req == nil {
+		return
+	}
+	state := getRequestState(req.Context())
+	if state == nil || state.ctx == nil {
+		return
+	}
+	state.once.Do(func() {
+		info := RequestInfo{
+			Operation: state.operation,
+			Realm:     state.realm,
+			Method:    req.Method,
+			Latency:   time.Since(state.start),
+			Err:       err,
+		}
+		if u, err := url.Parse(req.URL); err == nil {
+			u.RawQuery = ""
+			info.URL = u.String()
+		}
+		if resp != nil && resp.RawResponse != nil {
+			info.StatusCode = resp.StatusCode()
+		}
+		if retry := getRetryState(req.Context()); retry != nil {
+			info.Retries = retry.attempts
+		}
+		for i := len(state.instrumentations) - 1; i >= 0; i-- {
+			state.instrumentations[i].EndRequest(state.ctx, info)
+		}
+	})
+}
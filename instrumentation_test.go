@@ -0,0 +1,222 @@
+package gocloak
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingInstrumentation struct {
+	mu         sync.Mutex
+	operations []string
+	infos      []RequestInfo
+}
+
+type operationKey struct{}
+
+func (i *recordingInstrumentation) StartRequest(ctx context.Context, operation string) context.Context {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.operations = append(i.operations, operation)
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+func (i *recordingInstrumentation) EndRequest(ctx context.Context, info RequestInfo) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if ctx.Value(operationKey{}) == info.Operation {
+		i.infos = append(i.infos, info)
+	}
+}
+
+func TestInstrumentation(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/auth/admin/realms/my%20realm/users", "/auth/admin/realms/my realm/users":
+			_, _ = w.Write([]byte(`[{"id":"user"}]`))
+		case "/auth/realms/realm/protocol/openid-connect/token/introspect":
+			_, _ = w.Write([]byte(`{"active":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer server.Close()
+	instrumentation := &recordingInstrumentation{}
+
+	client := NewClient(server.URL, SetInstrumentation(instrumentation))
+	ctx := context.Background()
+	_, err := client.GetUsers(ctx, "token", "my realm", GetUsersParams{Search: StringP("name")})
+	assert.NoError(t, err)
+	_, err = client.RetrospectToken(ctx, "token", "client", "secret", "realm")
+	assert.NoError(t, err)
+	_, err = client.GetRealm(ctx, "token", "unknown")
+	assert.True(t, IsNotFound(err))
+
+	assert.Equal(t, []string{"GetUsers", "RetrospectToken", "GetRealm"}, instrumentation.operations)
+	infos := instrumentation.infos
+	if assert.Len(t, infos, 3) {
+		assert.Equal(t, "GetUsers", infos[0].Operation)
+		assert.Equal(t, "my realm", infos[0].Realm)
+		assert.Equal(t, http.MethodGet, infos[0].Method)
+		assert.Equal(t, server.URL+"/auth/admin/realms/my%20realm/users", infos[0].URL)
+		assert.Equal(t, http.StatusOK, infos[0].StatusCode)
+		assert.True(t, infos[0].Latency > 0)
+		assert.NoError(t, infos[0].Err)
+
+		assert.Equal(t, "RetrospectToken", infos[1].Operation)
+		assert.Equal(t, "realm", infos[1].Realm)
+		assert.Equal(t, http.MethodPost, infos[1].Method)
+
+		assert.Equal(t, "unknown", infos[2].Realm)
+		assert.Equal(t, http.StatusNotFound, infos[2].StatusCode)
+		assert.Equal(t, err, infos[2].Err)
+	}
+}
+
+func TestInstrumentation_Retries(t *testing.T) {
+	t.Parallel()
+	server, _ := flakyServer(t, 2, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+	first, second := &recordingInstrumentation{}, &recordingInstrumentation{}
+
+	client := NewClient(server.URL,
+		SetRetryPolicy(RetryPolicy{MaxRetries: 2, WaitTime: time.Millisecond}),
+		SetInstrumentation(first),
+		SetInstrumentation(second),
+	)
+	_, err := client.GetIssuer(context.Background(), "realm")
+	assert.NoError(t, err)
+	for _, instrumentation := range []*recordingInstrumentation{first, second} {
+		if assert.Len(t, instrumentation.infos, 1) {
+			assert.Equal(t, "GetIssuer", instrumentation.infos[0].Operation)
+			assert.Equal(t, 2, instrumentation.infos[0].Retries)
+			assert.Equal(t, http.StatusOK, instrumentation.infos[0].StatusCode)
+		}
+	}
+}
+
+// failingAuthenticator fails from the given call on
+type failingAuthenticator struct {
+	calls  int32
+	failAt int32
+}
+
+func (a *failingAuthenticator) Authenticate(req *resty.Request, clientID, audience string) error {
+	if atomic.AddInt32(&a.calls, 1) >= a.failAt {
+		return errors.New("no credentials")
+	}
+	req.SetBasicAuth(clientID, "secret")
+	return nil
+}
+
+func TestInstrumentation_EndsSentRequests(t *testing.T) {
+	t.Parallel()
+	server, _ := flakyServer(t, 1, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+	tokenOptions := func(authenticator ClientAuthenticator) TokenOptions {
+		return TokenOptions{
+			ClientID:            StringP("client"),
+			GrantType:           StringP("client_credentials"),
+			ClientAuthenticator: authenticator,
+		}
+	}
+
+	// requests which fail before they are sent are not instrumented
+	instrumentation := &recordingInstrumentation{}
+	client := NewClient(server.URL, SetInstrumentation(instrumentation))
+	_, err := client.GetToken(context.Background(), "realm", tokenOptions(&failingAuthenticator{failAt: 1}))
+	assert.Error(t, err)
+	client = NewClient(server.URL, SetCABundle([]byte("no certificate")), SetInstrumentation(instrumentation))
+	_, err = client.GetUsers(context.Background(), "token", "realm", GetUsersParams{})
+	assert.Error(t, err)
+	assert.Empty(t, instrumentation.operations)
+	assert.Empty(t, instrumentation.infos)
+
+	// a retry whose client authentication fails
+	instrumentation = &recordingInstrumentation{}
+	client = NewClient(server.URL,
+		SetRetryPolicy(RetryPolicy{MaxRetries: 1, WaitTime: time.Millisecond, RetryPOST: true}),
+		SetInstrumentation(instrumentation),
+	)
+	_, err = client.GetToken(context.Background(), "realm", tokenOptions(&failingAuthenticator{failAt: 2}))
+	assert.EqualError(t, err, "no credentials")
+	assert.Equal(t, []string{"GetToken"}, instrumentation.operations)
+	if assert.Len(t, instrumentation.infos, 1) {
+		assert.Equal(t, err, instrumentation.infos[0].Err)
+		assert.Equal(t, 1, instrumentation.infos[0].Retries)
+	}
+
+	// a request canceled while it waits for the retry
+	unavailable, _ := flakyServer(t, 2, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer unavailable.Close()
+	instrumentation = &recordingInstrumentation{}
+	client = NewClient(unavailable.URL,
+		SetRetryPolicy(RetryPolicy{MaxRetries: 1, WaitTime: time.Hour}),
+		SetInstrumentation(instrumentation),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.GetUsers(ctx, "token", "realm", GetUsersParams{})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, []string{"GetUsers"}, instrumentation.operations)
+	if assert.Len(t, instrumentation.infos, 1) {
+		assert.Equal(t, err, instrumentation.infos[0].Err)
+	}
+}
+
+func TestInstrumentation_Realm(t *testing.T) {
+	t.Parallel()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/auth/realms/realm/.well-known/openid-configuration":
+			_, _ = w.Write([]byte(`{"token_endpoint":"` + server.URL + `/oauth/token"}`))
+		case "/oauth/token":
+			_, _ = w.Write([]byte(`{"access_token":"token"}`))
+		case "/auth/admin/realms":
+			_, _ = w.Write([]byte(`[{"realm":"realm"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	instrumentation := &recordingInstrumentation{}
+
+	// the realm is reported for paths without it and for the default realm
+	client := NewClient(server.URL, SetOpenIDDiscovery(true), SetDefaultRealm("realm"), SetInstrumentation(instrumentation))
+	_, err := client.GetToken(context.Background(), "", TokenOptions{
+		ClientID:     StringP("client"),
+		ClientSecret: StringP("secret"),
+		GrantType:    StringP("client_credentials"),
+	})
+	assert.NoError(t, err)
+	_, err = client.GetRealms(context.Background(), "token")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"GetOpenIDConfiguration", "GetToken", "GetRealms"}, instrumentation.operations)
+	infos := instrumentation.infos
+	if assert.Len(t, infos, 3) {
+		assert.Equal(t, "realm", infos[0].Realm)
+		assert.Equal(t, "realm", infos[1].Realm)
+		assert.Equal(t, server.URL+"/oauth/token", infos[1].URL)
+		assert.Equal(t, "", infos[2].Realm)
+	}
+}
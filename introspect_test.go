@@ -0,0 +1,46 @@
+package gocloak
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGocloak_IntrospectToken(t *testing.T) {
+	t.Parallel()
+	hints := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/auth/realms/realm/protocol/openid-connect/token/introspect", r.URL.Path)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "token", r.PostForm.Get("token"))
+		hints <- r.PostForm.Get("token_type_hint")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"active":true,"sub":"f7c1","tenant":"acme"}`))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	ctx := context.Background()
+
+	result, err := client.IntrospectToken(ctx, "my-client", "secret", "realm", "token", TokenTypeHintAccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, TokenTypeHintAccessToken, <-hints)
+	assert.Equal(t, "f7c1", PString(result.Sub))
+	assert.Equal(t, "acme", result.Claims["tenant"])
+
+	_, err = client.RetrospectToken(ctx, "token", "my-client", "secret", "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, TokenTypeHintRequestingPartyToken, <-hints)
+
+	var claims struct {
+		Active bool   `json:"active"`
+		Tenant string `json:"tenant"`
+	}
+	err = client.IntrospectTokenCustomClaims(ctx, "my-client", "secret", "realm", "token", "", &claims)
+	assert.NoError(t, err)
+	assert.Empty(t, <-hints)
+	assert.True(t, claims.Active)
+	assert.Equal(t, "acme", claims.Tenant)
+}
@@ -0,0 +1,226 @@
+package gocloak
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// defaultIntrospectionNegativeCacheTTL is how long inactive tokens are cached by default
+const defaultIntrospectionNegativeCacheTTL = 5 * time.Second
+
+// IntrospectionCacheStats holds the counters of the introspection cache
+type IntrospectionCacheStats struct {
+	// Hits is the number of introspections served from the cache
+	Hits uint64
+	// Misses is the number of introspections which needed a request to the introspect endpoint
+	Misses uint64
+	// Evictions is the number of results removed to make room for newer ones
+	Evictions uint64
+	// Entries is the number of cached results
+	Entries int
+}
+
+type introspectionKey [sha256.Size]byte
+
+type introspectionEntry struct {
+	key       introspectionKey
+	result    RetrospecTokenResult
+	expiresAt time.Time
+}
+
+// introspectionCache is a concurrency-safe LRU cache of introspection results.
+// The tokens are keyed by their hash, so the cache holds no usable credentials
+type introspectionCache struct {
+	ttl         time.Duration
+	negativeTTL time.Duration
+	size        int
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[introspectionKey]*list.Element
+	lru     *list.List
+	stats   IntrospectionCacheStats
+}
+
+func newIntrospectionCache(ttl, negativeTTL time.Duration, size int) *introspectionCache {
+	return &introspectionCache{
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		size:        size,
+		now:         time.Now,
+		entries:     make(map[introspectionKey]*list.Element),
+		lru:         list.New(),
+	}
+}
+
+// introspectionCacheKey hashes the token together with everything else the result depends on
+func introspectionCacheKey(realm, clientID, tokenTypeHint, token string) introspectionKey {
+	return sha256.Sum256([]byte(makeURL(realm, clientID, tokenTypeHint) + "\x00" + token))
+}
+
+// get returns a copy of the cached result
+func (c *introspectionCache) get(key introspectionKey) (*RetrospecTokenResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	entry := element.Value.(*introspectionEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(element)
+		c.stats.Misses++
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	c.stats.Hits++
+	return copyRetrospecTokenResult(&entry.result), true
+}
+
+// set caches an active result until the token expires but at most for the TTL,
+// and an inactive result for the negative TTL
+func (c *introspectionCache) set(key introspectionKey, result *RetrospecTokenResult) {
+	now := c.now()
+	expiresAt := now.Add(c.negativeTTL)
+	if result.Active != nil && *result.Active {
+		expiresAt = now.Add(c.ttl)
+		if result.Exp != nil {
+			if exp := time.Unix(int64(*result.Exp), 0); exp.Before(expiresAt) {
+				expiresAt = exp
+			}
+		}
+	}
+	if !now.Before(expiresAt) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	for c.lru.Len() >= c.size {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+	c.entries[key] = c.lru.PushFront(&introspectionEntry{
+		key:       key,
+		result:    *copyRetrospecTokenResult(result),
+		expiresAt: expiresAt,
+	})
+}
+
+// copyRetrospecTokenResult returns a deep copy of the result, so callers cannot change the cached result
+func copyRetrospecTokenResult(result *RetrospecTokenResult) *RetrospecTokenResult {
+	copied := *result
+	if result.Permissions != nil {
+		permissions := make([]RequestingPartyPermission, len(*result.Permissions))
+		for i, permission := range *result.Permissions {
+			permission.ResourceID = copyStringP(permission.ResourceID)
+			permission.ResourceName = copyStringP(permission.ResourceName)
+			permission.Scopes = copyStringsP(permission.Scopes)
+			if permission.Claims != nil {
+				claims := make(map[string][]string, len(*permission.Claims))
+				for name, values := range *permission.Claims {
+					claims[name] = append(make([]string, 0, len(values)), values...)
+				}
+				permission.Claims = &claims
+			}
+			permissions[i] = permission
+		}
+		copied.Permissions = &permissions
+	}
+	copied.Exp = copyIntP(result.Exp)
+	copied.Nbf = copyIntP(result.Nbf)
+	copied.Iat = copyIntP(result.Iat)
+	if result.Aud != nil {
+		aud := append(make(StringOrArray, 0, len(*result.Aud)), *result.Aud...)
+		copied.Aud = &aud
+	}
+	if result.Active != nil {
+		copied.Active = BoolP(*result.Active)
+	}
+	copied.AuthTime = copyIntP(result.AuthTime)
+	copied.Jti = copyStringP(result.Jti)
+	copied.Type = copyStringP(result.Type)
+	copied.Iss = copyStringP(result.Iss)
+	copied.Sub = copyStringP(result.Sub)
+	copied.Azp = copyStringP(result.Azp)
+	copied.SessionState = copyStringP(result.SessionState)
+	copied.Scope = copyStringP(result.Scope)
+	copied.ClientID = copyStringP(result.ClientID)
+	copied.Username = copyStringP(result.Username)
+	copied.PreferredUsername = copyStringP(result.PreferredUsername)
+	copied.Email = copyStringP(result.Email)
+	if result.RealmAccess != nil {
+		copied.RealmAccess = &TokenAccess{Roles: copyStringsP(result.RealmAccess.Roles)}
+	}
+	if result.ResourceAccess != nil {
+		resourceAccess := make(map[string]TokenAccess, len(*result.ResourceAccess))
+		for clientID, access := range *result.ResourceAccess {
+			resourceAccess[clientID] = TokenAccess{Roles: copyStringsP(access.Roles)}
+		}
+		copied.ResourceAccess = &resourceAccess
+	}
+	if result.Claims != nil {
+		copied.Claims = copyJSONValue(result.Claims).(map[string]interface{})
+	}
+	return &copied
+}
+
+func copyStringP(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	return StringP(*value)
+}
+
+func copyIntP(value *int) *int {
+	if value == nil {
+		return nil
+	}
+	return IntP(*value)
+}
+
+func copyStringsP(values *[]string) *[]string {
+	if values == nil {
+		return nil
+	}
+	copied := append(make([]string, 0, len(*values)), *values...)
+	return &copied
+}
+
+// copyJSONValue returns a deep copy of a value decoded from JSON
+func copyJSONValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for name, item := range value {
+			copied[name] = copyJSONValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, item := range value {
+			copied[i] = copyJSONValue(item)
+		}
+		return copied
+	}
+	return value
+}
+
+func (c *introspectionCache) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*introspectionEntry).key)
+}
+
+func (c *introspectionCache) getStats() IntrospectionCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
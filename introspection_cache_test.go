@@ -0,0 +1,157 @@
+package gocloak
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntrospectionCache(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1000000, 0)
+	cache := newIntrospectionCache(time.Minute, 5*time.Second, 2)
+	cache.now = func() time.Time { return now }
+
+	short := introspectionCacheKey("realm", "my-client", "", "short")
+	long := introspectionCacheKey("realm", "my-client", "", "long")
+	inactive := introspectionCacheKey("realm", "my-client", "", "inactive")
+	exp := int(now.Add(10 * time.Second).Unix())
+	cache.set(short, &RetrospecTokenResult{Active: BoolP(true), Exp: &exp})
+	cache.set(long, &RetrospecTokenResult{Active: BoolP(true)})
+	cache.set(inactive, &RetrospecTokenResult{Active: BoolP(false)})
+
+	// the least recently used result has been evicted
+	_, ok := cache.get(short)
+	assert.False(t, ok)
+	result, ok := cache.get(long)
+	assert.True(t, ok)
+	assert.True(t, PBool(result.Active))
+	result, ok = cache.get(inactive)
+	assert.True(t, ok)
+	assert.False(t, PBool(result.Active))
+
+	// inactive results are cached briefly
+	now = now.Add(6 * time.Second)
+	_, ok = cache.get(inactive)
+	assert.False(t, ok)
+	_, ok = cache.get(long)
+	assert.True(t, ok)
+
+	// active results are cached until the token expires but at most for the TTL
+	cache.set(short, &RetrospecTokenResult{Active: BoolP(true), Exp: &exp})
+	now = now.Add(5 * time.Second)
+	_, ok = cache.get(short)
+	assert.False(t, ok)
+	now = now.Add(time.Minute)
+	_, ok = cache.get(long)
+	assert.False(t, ok)
+
+	assert.Equal(t, IntrospectionCacheStats{
+		Hits:      3,
+		Misses:    4,
+		Evictions: 1,
+		Entries:   0,
+	}, cache.getStats())
+}
+
+func TestIntrospectionCache_Copies(t *testing.T) {
+	t.Parallel()
+	cache := newIntrospectionCache(time.Minute, 5*time.Second, 10)
+	key := introspectionCacheKey("realm", "my-client", "", "token")
+	cache.set(key, &RetrospecTokenResult{
+		Active:         BoolP(true),
+		Permissions:    &[]RequestingPartyPermission{{ResourceName: StringP("resource"), Scopes: &[]string{"read"}}},
+		RealmAccess:    &TokenAccess{Roles: &[]string{"user"}},
+		ResourceAccess: &map[string]TokenAccess{"client": {Roles: &[]string{"admin"}}},
+		Claims:         map[string]interface{}{"tenant": map[string]interface{}{"id": "a"}},
+	})
+
+	result, ok := cache.get(key)
+	assert.True(t, ok)
+	*result.Active = false
+	(*result.Permissions)[0].ResourceName = StringP("changed")
+	(*(*result.Permissions)[0].Scopes)[0] = "changed"
+	(*result.RealmAccess.Roles)[0] = "changed"
+	(*result.ResourceAccess)["other"] = TokenAccess{}
+	result.Claims["tenant"].(map[string]interface{})["id"] = "b"
+
+	result, ok = cache.get(key)
+	assert.True(t, ok)
+	assert.True(t, PBool(result.Active))
+	assert.Equal(t, "resource", PString((*result.Permissions)[0].ResourceName))
+	assert.Equal(t, []string{"read"}, *(*result.Permissions)[0].Scopes)
+	assert.Equal(t, []string{"user"}, *result.RealmAccess.Roles)
+	assert.Len(t, *result.ResourceAccess, 1)
+	assert.Equal(t, "a", result.Claims["tenant"].(map[string]interface{})["id"])
+}
+
+func TestIntrospectionCache_WithoutActive(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1000000, 0)
+	cache := newIntrospectionCache(time.Minute, 5*time.Second, 10)
+	cache.now = func() time.Time { return now }
+	key := introspectionCacheKey("realm", "my-client", "", "token")
+
+	// a result without active is cached like an inactive one
+	cache.set(key, &RetrospecTokenResult{})
+	result, ok := cache.get(key)
+	assert.True(t, ok)
+	assert.Nil(t, result.Active)
+	now = now.Add(6 * time.Second)
+	_, ok = cache.get(key)
+	assert.False(t, ok)
+}
+
+func TestIntrospectionCacheKey(t *testing.T) {
+	t.Parallel()
+	key := introspectionCacheKey("realm", "my-client", "", "token")
+	assert.Equal(t, key, introspectionCacheKey("realm", "my-client", "", "token"))
+	assert.NotEqual(t, key, introspectionCacheKey("realm", "other-client", "", "token"))
+	assert.NotEqual(t, key, introspectionCacheKey("realm", "my-client", TokenTypeHintAccessToken, "token"))
+	assert.NotEqual(t, key, introspectionCacheKey("realm", "my-client", "", "other-token"))
+}
+
+func TestGocloak_IntrospectToken_Cache(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("token") == "revoked" {
+			_, _ = w.Write([]byte(`{"active":false}`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"active":true,"exp":%d}`, time.Now().Add(time.Hour).Unix())
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, SetIntrospectionCache(time.Minute, 100))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		result, err := client.RetrospectToken(ctx, "token", "my-client", "secret", "realm")
+		assert.NoError(t, err)
+		assert.True(t, PBool(result.Active))
+		result, err = client.RetrospectToken(ctx, "revoked", "my-client", "secret", "realm")
+		assert.NoError(t, err)
+		assert.False(t, PBool(result.Active))
+	}
+	assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+	stats := client.GetIntrospectionCacheStats()
+	assert.EqualValues(t, 4, stats.Hits)
+	assert.EqualValues(t, 2, stats.Misses)
+	assert.Equal(t, 2, stats.Entries)
+
+	// the cache is opt-in
+	client = NewClient(server.URL)
+	_, err := client.RetrospectToken(ctx, "token", "my-client", "secret", "realm")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&requests))
+	assert.Equal(t, IntrospectionCacheStats{}, client.GetIntrospectionCacheStats())
+}
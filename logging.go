@@ -0,0 +1,83 @@
+package gocloak
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// redacted replaces the secrets in the logs
+const redacted = "**REDACTED**"
+
+// sensitiveHeaders are the headers which carry credentials
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// sensitiveFields are the form and JSON fields which carry passwords, client secrets or tokens
+var sensitiveFields = strings.Join([]string{
+	"password", "value", "secret", "client_secret", "clientSecret", "secretData", "credentialData", "bindCredential",
+	"token", "access_token", "refresh_token", "id_token", "subject_token", "client_assertion", "code", "device_code",
+	"privateKey", "private_key",
+}, "|")
+
+var (
+	sensitiveJSONField = regexp.MustCompile(`(?i)("(?:` + sensitiveFields + `)"\s*:\s*)(?:"(?:[^"\\]|\\.)*"|\[[^\]]*\])`)
+	sensitiveFormField = regexp.MustCompile(`(?i)(^|[&?\s])((?:` + sensitiveFields + `)=)[^&\s]*`)
+)
+
+// redactSecrets replaces the values of the sensitive JSON and form fields in the text
+func redactSecrets(text string) string {
+	text = sensitiveJSONField.ReplaceAllString(text, `${1}"`+redacted+`"`)
+	return sensitiveFormField.ReplaceAllString(text, "${1}${2}"+redacted)
+}
+
+func redactHeaders(header map[string][]string) {
+	for _, name := range sensitiveHeaders {
+		for key := range header {
+			if strings.EqualFold(key, name) {
+				header[key] = []string{redacted}
+			}
+		}
+	}
+}
+
+// redactDebugLog removes the credentials from the requests and responses logged in debug mode
+func (client *gocloak) redactDebugLog() {
+	client.restyClient.
+		OnRequestLog(func(log *resty.RequestLog) error {
+			redactHeaders(log.Header)
+			log.Body = redactSecrets(log.Body)
+			return nil
+		}).
+		OnResponseLog(func(log *resty.ResponseLog) error {
+			redactHeaders(log.Header)
+			log.Body = redactSecrets(log.Body)
+			return nil
+		})
+}
+
+// NewLoggingInstrumentation returns an instrumentation which logs a line per request.
+// The lines hold no headers or bodies, so no passwords, client secrets or tokens are logged
+func NewLoggingInstrumentation(logger Logger) Instrumentation {
+	return &loggingInstrumentation{logger: logger}
+}
+
+type loggingInstrumentation struct {
+	logger Logger
+}
+
+func (l *loggingInstrumentation) StartRequest(ctx context.Context, operation string) context.Context {
+	return ctx
+}
+
+func (l *loggingInstrumentation) EndRequest(ctx context.Context, info RequestInfo) {
+	line := fmt.Sprintf("operation=%s realm=%q method=%s url=%q status=%d latency=%s retries=%d",
+		info.Operation, info.Realm, info.Method, info.URL, info.StatusCode, info.Latency, info.Retries)
+	if info.Err != nil {
+		l.logger.Warnf("%s error=%q", line, redactSecrets(info.Err.Error()))
+		return
+	}
+	l.logger.Debugf("%s", line)
+}
@@ -0,0 +1,90 @@
+package gocloak
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bufferLogger struct {
+	mu      sync.Mutex
+	builder strings.Builder
+}
+
+func (l *bufferLogger) Errorf(format string, v ...interface{}) { l.printf(format, v...) }
+func (l *bufferLogger) Warnf(format string, v ...interface{})  { l.printf(format, v...) }
+func (l *bufferLogger) Debugf(format string, v ...interface{}) { l.printf(format, v...) }
+
+func (l *bufferLogger) printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.builder.WriteString(fmt.Sprintf(format, v...) + "\n")
+}
+
+func (l *bufferLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.builder.String()
+}
+
+func TestRedactSecrets(t *testing.T) {
+	t.Parallel()
+	for text, expected := range map[string]string{
+		"grant_type=password&username=user&password=pass%21&client_secret=secret": "grant_type=password&username=user&password=**REDACTED**&client_secret=**REDACTED**",
+		"token=abc&token_type_hint=access_token":                                  "token=**REDACTED**&token_type_hint=access_token",
+		`{"access_token": "abc", "expires_in": 300, "refresh_token":"d\"ef"}`:     `{"access_token": "**REDACTED**", "expires_in": 300, "refresh_token":"**REDACTED**"}`,
+		`{"type": "password", "value": "pass", "temporary": false}`:               `{"type": "password", "value": "**REDACTED**", "temporary": false}`,
+		`{"config": {"clientSecret": ["secret"], "clientId": ["client"]}}`:        `{"config": {"clientSecret": "**REDACTED**", "clientId": ["client"]}}`,
+	} {
+		assert.Equal(t, expected, redactSecrets(text))
+	}
+}
+
+func TestGocloak_DebugLogRedacted(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"access-token-value","refresh_token":"refresh-token-value","token_type":"bearer"}`))
+	}))
+	defer server.Close()
+	logger := &bufferLogger{}
+
+	client := NewClient(server.URL, SetLogger(logger), SetDebug(true))
+	_, err := client.Login(context.Background(), "client", "client-secret-value", "realm", "user", "password-value")
+	assert.NoError(t, err)
+	err = client.SetPassword(context.Background(), "bearer-token-value", "user", "realm", "new-password-value", false)
+	assert.NoError(t, err)
+
+	log := logger.String()
+	assert.Contains(t, log, "token_type")
+	for _, secret := range []string{
+		"access-token-value", "refresh-token-value", "client-secret-value", "password-value", "new-password-value", "bearer-token-value",
+		// the client credentials in the basic authorization header
+		"Y2xpZW50OmNsaWVudC1zZWNyZXQtdmFsdWU=",
+	} {
+		assert.NotContains(t, log, secret)
+	}
+}
+
+func TestLoggingInstrumentation(t *testing.T) {
+	t.Parallel()
+	logger := &bufferLogger{}
+	instrumentation := NewLoggingInstrumentation(logger)
+	ctx := instrumentation.StartRequest(context.Background(), "GetUsers")
+	instrumentation.EndRequest(ctx, RequestInfo{
+		Operation:  "GetUsers",
+		Realm:      "realm",
+		Method:     http.MethodGet,
+		URL:        "http://keycloak/auth/admin/realms/realm/users",
+		StatusCode: http.StatusUnauthorized,
+		Retries:    1,
+		Err:        &APIError{Code: http.StatusUnauthorized, Message: "401 Unauthorized"},
+	})
+	assert.Equal(t, `operation=GetUsers realm="realm" method=GET url="http://keycloak/auth/admin/realms/realm/users" status=401 latency=0s retries=1 error="401 Unauthorized"`+"\n", logger.String())
+}
@@ -0,0 +1,74 @@
+package gocloak
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGocloak_RevokeToken(t *testing.T) {
+	t.Parallel()
+	forms := make(chan url.Values, 1)
+	server := formServer(t, forms)
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	err := client.RevokeToken(context.Background(), "my-client", "secret", "realm", "refresh", "refresh_token")
+	assert.NoError(t, err)
+	form := <-forms
+	assert.Equal(t, "refresh", form.Get("token"))
+	assert.Equal(t, "refresh_token", form.Get("token_type_hint"))
+	assert.Equal(t, "my-client", form.Get("basic_user"))
+
+	err = client.RevokeToken(context.Background(), "my-client", "", "realm", "access", "")
+	assert.NoError(t, err)
+	form = <-forms
+	assert.Equal(t, "my-client", form.Get("client_id"))
+	_, ok := form["token_type_hint"]
+	assert.False(t, ok)
+	assert.Empty(t, form.Get("basic_user"))
+}
+
+func TestGocloak_AdminLogout(t *testing.T) {
+	t.Parallel()
+	requests := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		requests <- r.Method + " " + r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/auth/admin/realms/realm/logout-all", "/auth/admin/realms/realm/push-revocation",
+			"/auth/admin/realms/realm/clients/7f3a/push-revocation":
+			_, _ = w.Write([]byte(`{"successRequests":["http://app/admin"],"failedRequests":[]}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	ctx := context.Background()
+
+	assert.NoError(t, client.LogoutAllSessions(ctx, "token", "realm", "user-id"))
+	assert.Equal(t, "POST /auth/admin/realms/realm/users/user-id/logout", <-requests)
+
+	assert.NoError(t, client.LogoutUserSession(ctx, "token", "realm", "session-id"))
+	assert.Equal(t, "DELETE /auth/admin/realms/realm/sessions/session-id", <-requests)
+
+	result, err := client.LogoutAllRealmSessions(ctx, "token", "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, "POST /auth/admin/realms/realm/logout-all", <-requests)
+	assert.Equal(t, []string{"http://app/admin"}, *result.SuccessRequests)
+
+	result, err = client.PushRealmRevocation(ctx, "token", "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, "POST /auth/admin/realms/realm/push-revocation", <-requests)
+	assert.Empty(t, *result.FailedRequests)
+
+	_, err = client.PushClientRevocation(ctx, "token", "realm", "7f3a")
+	assert.NoError(t, err)
+	assert.Equal(t, "POST /auth/admin/realms/realm/clients/7f3a/push-revocation", <-requests)
+}
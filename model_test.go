@@ -85,3 +85,58 @@ func TestGetQueryParams(t *testing.T) {
 		params,
 	)
 }
+
+func TestTokenOptions_FormData_TokenExchange(t *testing.T) {
+	t.Parallel()
+
+	options := TokenOptions{
+		ClientID:           StringP("gateway"),
+		ClientSecret:       StringP("secret"),
+		GrantType:          StringP("urn:ietf:params:oauth:grant-type:token-exchange"),
+		SubjectToken:       StringP("token"),
+		SubjectTokenType:   StringP(TokenTypeAccessToken),
+		RequestedTokenType: StringP(TokenTypeRefreshToken),
+		Audience:           StringP("downstream"),
+		RequestedSubject:   StringP("john"),
+	}
+	formData := options.FormData()
+	assert.Equal(t, "token", formData["subject_token"])
+	assert.Equal(t, TokenTypeAccessToken, formData["subject_token_type"])
+	assert.Equal(t, TokenTypeRefreshToken, formData["requested_token_type"])
+	assert.Equal(t, "downstream", formData["audience"])
+	assert.Equal(t, "john", formData["requested_subject"])
+	assert.NotContains(t, formData, "client_secret")
+	assert.NotContains(t, formData, "subject_issuer")
+}
+
+func TestRetrospecTokenResult_Unmarshal(t *testing.T) {
+	t.Parallel()
+	var result RetrospecTokenResult
+	err := json.Unmarshal([]byte(`{
+		"active": true,
+		"sub": "f7c1",
+		"username": "jdoe",
+		"client_id": "my-client",
+		"scope": "openid email",
+		"realm_access": {"roles": ["user"]},
+		"resource_access": {"my-client": {"roles": ["reader"]}},
+		"permissions": [{"rsid": "7f3a", "rsname": "Orders", "scopes": ["view"]}],
+		"tenant": "acme",
+		"groups": ["/staff"]
+	}`), &result)
+	assert.NoError(t, err)
+	assert.True(t, PBool(result.Active))
+	assert.Equal(t, "f7c1", PString(result.Sub))
+	assert.Equal(t, "jdoe", PString(result.Username))
+	assert.Equal(t, "my-client", PString(result.ClientID))
+	assert.Equal(t, "openid email", PString(result.Scope))
+	assert.Equal(t, []string{"user"}, *result.RealmAccess.Roles)
+	assert.Equal(t, []string{"reader"}, *(*result.ResourceAccess)["my-client"].Roles)
+	assert.Len(t, *result.Permissions, 1)
+	assert.Equal(t, "Orders", PString((*result.Permissions)[0].ResourceName))
+	assert.Equal(t, []string{"view"}, *(*result.Permissions)[0].Scopes)
+	assert.Equal(t, map[string]interface{}{
+		"tenant": "acme",
+		"groups": []interface{}{"/staff"},
+	}, result.Claims)
+}
@@ -2,6 +2,8 @@ package gocloak
 
 import (
 	"encoding/json"
+	"net/url"
+	"reflect"
 	"strings"
 )
 
@@ -54,17 +56,6 @@ func (s *StringOrArray) MarshalJSON() ([]byte, error) {
 	return json.Marshal([]string(*s))
 }
 
-// APIError represents an api error
-type APIError struct {
-	Code    int
-	Message string
-}
-
-// Error stringifies the APIError
-func (apiError APIError) Error() string {
-	return apiError.Message
-}
-
 // CertResponseKey is returned by the certs endpoint
 type CertResponseKey struct {
 	Kid *string `json:"kid,omitempty"`
@@ -73,6 +64,9 @@ type CertResponseKey struct {
 	Use *string `json:"use,omitempty"`
 	N   *string `json:"n,omitempty"`
 	E   *string `json:"e,omitempty"`
+	Crv *string `json:"crv,omitempty"`
+	X   *string `json:"x,omitempty"`
+	Y   *string `json:"y,omitempty"`
 }
 
 // CertResponse is returned by the certs endpoint
@@ -89,17 +83,103 @@ type IssuerResponse struct {
 	TokensNotBefore *int    `json:"tokens-not-before,omitempty"`
 }
 
-// RetrospecTokenResult is returned when a token was checked
+// OpenIDConfiguration is the discovery document of a realm
+type OpenIDConfiguration struct {
+	Issuer                                     *string   `json:"issuer,omitempty"`
+	AuthorizationEndpoint                      *string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint                              *string   `json:"token_endpoint,omitempty"`
+	IntrospectionEndpoint                      *string   `json:"introspection_endpoint,omitempty"`
+	UserInfoEndpoint                           *string   `json:"userinfo_endpoint,omitempty"`
+	EndSessionEndpoint                         *string   `json:"end_session_endpoint,omitempty"`
+	JwksURI                                    *string   `json:"jwks_uri,omitempty"`
+	CheckSessionIframe                         *string   `json:"check_session_iframe,omitempty"`
+	RegistrationEndpoint                       *string   `json:"registration_endpoint,omitempty"`
+	RevocationEndpoint                         *string   `json:"revocation_endpoint,omitempty"`
+	DeviceAuthorizationEndpoint                *string   `json:"device_authorization_endpoint,omitempty"`
+	PushedAuthorizationRequestEndpoint         *string   `json:"pushed_authorization_request_endpoint,omitempty"`
+	GrantTypesSupported                        *[]string `json:"grant_types_supported,omitempty"`
+	ResponseTypesSupported                     *[]string `json:"response_types_supported,omitempty"`
+	ResponseModesSupported                     *[]string `json:"response_modes_supported,omitempty"`
+	SubjectTypesSupported                      *[]string `json:"subject_types_supported,omitempty"`
+	IDTokenSigningAlgValuesSupported           *[]string `json:"id_token_signing_alg_values_supported,omitempty"`
+	UserInfoSigningAlgValuesSupported          *[]string `json:"userinfo_signing_alg_values_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported          *[]string `json:"token_endpoint_auth_methods_supported,omitempty"`
+	TokenEndpointAuthSigningAlgValuesSupported *[]string `json:"token_endpoint_auth_signing_alg_values_supported,omitempty"`
+	ClaimsSupported                            *[]string `json:"claims_supported,omitempty"`
+	ClaimTypesSupported                        *[]string `json:"claim_types_supported,omitempty"`
+	ScopesSupported                            *[]string `json:"scopes_supported,omitempty"`
+	CodeChallengeMethodsSupported              *[]string `json:"code_challenge_methods_supported,omitempty"`
+	ClaimsParameterSupported                   *bool     `json:"claims_parameter_supported,omitempty"`
+	RequestParameterSupported                  *bool     `json:"request_parameter_supported,omitempty"`
+	RequestURIParameterSupported               *bool     `json:"request_uri_parameter_supported,omitempty"`
+	TLSClientCertificateBoundAccessTokens      *bool     `json:"tls_client_certificate_bound_access_tokens,omitempty"`
+}
+
+// RetrospecTokenResult is returned when a token was checked.
+// The claims without a field are kept in Claims
 type RetrospecTokenResult struct {
-	Permissions map[string]string `json:"permissions,omitempty"`
-	Exp         *int              `json:"exp,omitempty"`
-	Nbf         *int              `json:"nbf,omitempty"`
-	Iat         *int              `json:"iat,omitempty"`
-	Aud         *StringOrArray    `json:"aud,omitempty"`
-	Active      *bool             `json:"active"`
-	AuthTime    *int              `json:"auth_time,omitempty"`
-	Jti         *string           `json:"jti,omitempty"`
-	Type        *string           `json:"typ,omitempty"`
+	Permissions       *[]RequestingPartyPermission `json:"permissions,omitempty"`
+	Exp               *int                         `json:"exp,omitempty"`
+	Nbf               *int                         `json:"nbf,omitempty"`
+	Iat               *int                         `json:"iat,omitempty"`
+	Aud               *StringOrArray               `json:"aud,omitempty"`
+	Active            *bool                        `json:"active"`
+	AuthTime          *int                         `json:"auth_time,omitempty"`
+	Jti               *string                      `json:"jti,omitempty"`
+	Type              *string                      `json:"typ,omitempty"`
+	Iss               *string                      `json:"iss,omitempty"`
+	Sub               *string                      `json:"sub,omitempty"`
+	Azp               *string                      `json:"azp,omitempty"`
+	SessionState      *string                      `json:"session_state,omitempty"`
+	Scope             *string                      `json:"scope,omitempty"`
+	ClientID          *string                      `json:"client_id,omitempty"`
+	Username          *string                      `json:"username,omitempty"`
+	PreferredUsername *string                      `json:"preferred_username,omitempty"`
+	Email             *string                      `json:"email,omitempty"`
+	RealmAccess       *TokenAccess                 `json:"realm_access,omitempty"`
+	ResourceAccess    *map[string]TokenAccess      `json:"resource_access,omitempty"`
+	Claims            map[string]interface{}       `json:"-"`
+}
+
+// retrospecTokenResultFields are the JSON names of the claims with a field in RetrospecTokenResult
+var retrospecTokenResultFields = jsonFieldNames(reflect.TypeOf(RetrospecTokenResult{}))
+
+// UnmarshalJSON decodes the claims into the fields and keeps the other claims in Claims
+func (r *RetrospecTokenResult) UnmarshalJSON(data []byte) error {
+	type result RetrospecTokenResult
+	if err := json.Unmarshal(data, (*result)(r)); err != nil {
+		return err
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return err
+	}
+	for _, name := range retrospecTokenResultFields {
+		delete(claims, name)
+	}
+	r.Claims = nil
+	if len(claims) > 0 {
+		r.Claims = claims
+	}
+	return nil
+}
+
+// TokenAccess holds the roles of the realm_access and resource_access claims
+type TokenAccess struct {
+	Roles *[]string `json:"roles,omitempty"`
+}
+
+// jsonFieldNames returns the JSON names of the fields of the struct type
+func jsonFieldNames(t reflect.Type) []string {
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
 }
 
 // User represents the Keycloak User Structure
@@ -236,6 +316,34 @@ type GetGroupsParams struct {
 	Full   *bool   `json:"full,string,omitempty"`
 }
 
+// GetRoleParams represents the optional parameters for getting roles
+type GetRoleParams struct {
+	First               *int    `json:"first,string,omitempty"`
+	Max                 *int    `json:"max,string,omitempty"`
+	Search              *string `json:"search,omitempty"`
+	BriefRepresentation *bool   `json:"briefRepresentation,string,omitempty"`
+}
+
+// GetUsersByRoleParams represents the optional parameters for getting the users of a role
+type GetUsersByRoleParams struct {
+	First *int `json:"first,string,omitempty"`
+	Max   *int `json:"max,string,omitempty"`
+}
+
+// GetUserGroupsParams represents the optional parameters for getting the groups of a user
+type GetUserGroupsParams struct {
+	First               *int    `json:"first,string,omitempty"`
+	Max                 *int    `json:"max,string,omitempty"`
+	Search              *string `json:"search,omitempty"`
+	BriefRepresentation *bool   `json:"briefRepresentation,string,omitempty"`
+}
+
+// GetClientUserSessionsParams represents the optional parameters for getting the user sessions of a client
+type GetClientUserSessionsParams struct {
+	First *int `json:"first,string,omitempty"`
+	Max   *int `json:"max,string,omitempty"`
+}
+
 // Role is a role
 type Role struct {
 	ID                 *string             `json:"id,omitempty"`
@@ -435,6 +543,8 @@ type ProtocolMapperRepresentation struct {
 type GetClientsParams struct {
 	ClientID     *string `json:"clientId,omitempty"`
 	ViewableOnly *bool   `json:"viewableOnly,string"`
+	First        *int    `json:"first,string,omitempty"`
+	Max          *int    `json:"max,string,omitempty"`
 }
 
 // UserInfo is returned by the userinfo endpoint
@@ -569,17 +679,30 @@ type CredentialRepresentation struct {
 
 // TokenOptions represents the options to obtain a token
 type TokenOptions struct {
-	ClientID      *string  `json:"client_id"`
-	ClientSecret  *string  `json:"-"`
-	GrantType     *string  `json:"grant_type"`
-	RefreshToken  *string  `json:"refresh_token,omitempty"`
-	Scopes        []string `json:"-"`
-	Scope         *string  `json:"scope,omitempty"`
-	ResponseTypes []string `json:"-"`
-	ResponseType  *string  `json:"response_type,omitempty"`
-	Permission    *string  `json:"permission,omitempty"`
-	Username      *string  `json:"username,omitempty"`
-	Password      *string  `json:"password,omitempty"`
+	ClientID     *string `json:"client_id"`
+	ClientSecret *string `json:"-"`
+	// ClientAuthenticator overrides how the client authenticates, e.g. with private_key_jwt
+	ClientAuthenticator ClientAuthenticator `json:"-"`
+	GrantType           *string             `json:"grant_type"`
+	RefreshToken        *string             `json:"refresh_token,omitempty"`
+	Scopes              []string            `json:"-"`
+	Scope               *string             `json:"scope,omitempty"`
+	ResponseTypes       []string            `json:"-"`
+	ResponseType        *string             `json:"response_type,omitempty"`
+	Permission          *string             `json:"permission,omitempty"`
+	Username            *string             `json:"username,omitempty"`
+	Password            *string             `json:"password,omitempty"`
+	Code                *string             `json:"code,omitempty"`
+	RedirectURI         *string             `json:"redirect_uri,omitempty"`
+	CodeVerifier        *string             `json:"code_verifier,omitempty"`
+	// token exchange
+	SubjectToken       *string `json:"subject_token,omitempty"`
+	SubjectTokenType   *string `json:"subject_token_type,omitempty"`
+	SubjectIssuer      *string `json:"subject_issuer,omitempty"`
+	RequestedTokenType *string `json:"requested_token_type,omitempty"`
+	RequestedSubject   *string `json:"requested_subject,omitempty"`
+	RequestedIssuer    *string `json:"requested_issuer,omitempty"`
+	Audience           *string `json:"audience,omitempty"`
 }
 
 // FormData returns a map of options to be used in SetFormData function
@@ -590,15 +713,37 @@ func (t *TokenOptions) FormData() map[string]string {
 	if len(t.ResponseTypes) > 0 {
 		t.ResponseType = StringP(strings.Join(t.ResponseTypes, " "))
 	}
-	if NilOrEmpty(t.ResponseType) {
-		t.ResponseType = StringP("token")
-	}
 	m, _ := json.Marshal(t)
 	var res map[string]string
 	_ = json.Unmarshal(m, &res)
 	return res
 }
 
+// AuthCodeURLOptions holds the parameters of the authorization request of the authorization code flow
+type AuthCodeURLOptions struct {
+	ClientID            *string  `json:"client_id"`
+	RedirectURI         *string  `json:"redirect_uri,omitempty"`
+	ResponseType        *string  `json:"response_type"`
+	Scopes              []string `json:"-"`
+	Scope               *string  `json:"scope,omitempty"`
+	State               *string  `json:"state,omitempty"`
+	Nonce               *string  `json:"nonce,omitempty"`
+	CodeChallenge       *string  `json:"code_challenge,omitempty"`
+	CodeChallengeMethod *string  `json:"code_challenge_method,omitempty"`
+	Prompt              *string  `json:"prompt,omitempty"`
+	LoginHint           *string  `json:"login_hint,omitempty"`
+}
+
+// DeviceAuthorization is returned by the device authorization endpoint
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+}
+
 // UserSessionRepresentation represents a list of user's sessions
 type UserSessionRepresentation struct {
 	Clients    map[string]string `json:"clients,omitempty"`
@@ -610,6 +755,12 @@ type UserSessionRepresentation struct {
 	Username   *string           `json:"username,omitempty"`
 }
 
+// GlobalRequestResult is returned by the requests which are forwarded to the admin URLs of the clients
+type GlobalRequestResult struct {
+	SuccessRequests *[]string `json:"successRequests,omitempty"`
+	FailedRequests  *[]string `json:"failedRequests,omitempty"`
+}
+
 // SystemInfoRepresentation represents a system info
 type SystemInfoRepresentation struct {
 	FileEncoding   *string `json:"fileEncoding"`
@@ -648,3 +799,64 @@ type ServerInfoRepesentation struct {
 	SystemInfo *SystemInfoRepresentation `json:"systemInfo,omitempty"`
 	MemoryInfo *MemoryInfoRepresentation `json:"memoryInfo"`
 }
+
+// RequestingPartyTokenOptions represents the options to obtain a requesting party token with the uma-ticket grant
+type RequestingPartyTokenOptions struct {
+	GrantType                   *string   `json:"grant_type,omitempty"`
+	Ticket                      *string   `json:"ticket,omitempty"`
+	ClaimToken                  *string   `json:"claim_token,omitempty"`
+	ClaimTokenFormat            *string   `json:"claim_token_format,omitempty"`
+	RPT                         *string   `json:"rpt,omitempty"`
+	Permissions                 *[]string `json:"-"`
+	Audience                    *string   `json:"audience,omitempty"`
+	ResponseIncludeResourceName *bool     `json:"response_include_resource_name,string,omitempty"`
+	ResponsePermissionsLimit    *int      `json:"response_permissions_limit,string,omitempty"`
+	SubmitRequest               *bool     `json:"submit_request,string,omitempty"`
+	ResponseMode                *string   `json:"response_mode,omitempty"`
+}
+
+// FormData returns the form of the uma-ticket grant, every permission is sent as its own permission parameter
+func (t *RequestingPartyTokenOptions) FormData() (url.Values, error) {
+	if NilOrEmpty(t.GrantType) {
+		t.GrantType = StringP("urn:ietf:params:oauth:grant-type:uma-ticket")
+	}
+	params, err := GetQueryParams(t)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{}
+	for key, value := range params {
+		form.Set(key, value)
+	}
+	if t.Permissions != nil {
+		for _, permission := range *t.Permissions {
+			form.Add("permission", permission)
+		}
+	}
+	return form, nil
+}
+
+// RequestingPartyPermission is a permission returned with the permissions response mode
+type RequestingPartyPermission struct {
+	Claims       *map[string][]string `json:"claims,omitempty"`
+	ResourceID   *string              `json:"rsid,omitempty"`
+	ResourceName *string              `json:"rsname,omitempty"`
+	Scopes       *[]string            `json:"scopes,omitempty"`
+}
+
+// RequestingPartyPermissionDecision is returned with the decision response mode
+type RequestingPartyPermissionDecision struct {
+	Result *bool `json:"result,omitempty"`
+}
+
+// CreatePermissionTicketParams is a permission requested by a resource server on behalf of a client
+type CreatePermissionTicketParams struct {
+	ResourceID     *string              `json:"resource_id,omitempty"`
+	ResourceScopes *[]string            `json:"resource_scopes,omitempty"`
+	Claims         *map[string][]string `json:"claims,omitempty"`
+}
+
+// PermissionTicketResponseRepresentation is returned when a permission ticket has been created
+type PermissionTicketResponseRepresentation struct {
+	Ticket *string `json:"ticket,omitempty"`
+}
@@ -0,0 +1,310 @@
+package gocloak
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrIteratorDone is returned by the Next methods of the iterators when there are no more results
+var ErrIteratorDone = errors.New("no more results in iterator")
+
+// defaultPageSize is the number of results requested per page, which is also the default page size of keycloak
+const defaultPageSize = 100
+
+// pager requests the pages of a paginated list endpoint lazily.
+// The iterators embed it and keep the results of the current page
+type pager struct {
+	pageSize int
+	first    int
+	// remaining is the number of results which may still be returned, unlimited if negative
+	remaining int
+	// fetch requests the page and returns its number of results
+	fetch func(first, max int) (int, error)
+
+	index int
+	size  int
+	last  bool
+	err   error
+}
+
+func newPager(first, max *int, fetch func(first, max int) (int, error)) pager {
+	p := pager{
+		pageSize:  defaultPageSize,
+		remaining: -1,
+		fetch:     fetch,
+	}
+	if first != nil {
+		p.first = *first
+	}
+	if max != nil {
+		p.remaining = *max
+	}
+	return p
+}
+
+// SetPageSize sets the number of results requested per page, 100 by default.
+// It takes effect with the next requested page
+func (p *pager) SetPageSize(pageSize int) {
+	if pageSize > 0 {
+		p.pageSize = pageSize
+	}
+}
+
+// next returns the index of the next result in the current page, requesting the next page if needed
+func (p *pager) next() (int, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+	if p.remaining == 0 {
+		p.err = ErrIteratorDone
+		return 0, p.err
+	}
+	if p.index >= p.size {
+		if p.last {
+			p.err = ErrIteratorDone
+			return 0, p.err
+		}
+		max := p.pageSize
+		if p.remaining >= 0 && p.remaining < max {
+			max = p.remaining
+		}
+		size, err := p.fetch(p.first, max)
+		if err != nil {
+			p.err = err
+			return 0, err
+		}
+		p.first += size
+		p.index, p.size = 0, size
+		// a short page is the last one
+		p.last = size < max
+		if size == 0 {
+			p.err = ErrIteratorDone
+			return 0, p.err
+		}
+	}
+	if p.remaining > 0 {
+		p.remaining--
+	}
+	p.index++
+	return p.index - 1, nil
+}
+
+// UserIterator iterates over users
+type UserIterator struct {
+	pager
+	page []*User
+}
+
+// Next returns the next user, or ErrIteratorDone if there are no more users
+func (it *UserIterator) Next() (*User, error) {
+	i, err := it.next()
+	if err != nil {
+		return nil, err
+	}
+	return it.page[i], nil
+}
+
+// GroupIterator iterates over groups
+type GroupIterator struct {
+	pager
+	page []*Group
+}
+
+// Next returns the next group, or ErrIteratorDone if there are no more groups
+func (it *GroupIterator) Next() (*Group, error) {
+	i, err := it.next()
+	if err != nil {
+		return nil, err
+	}
+	return it.page[i], nil
+}
+
+// UserGroupIterator iterates over the groups of a user
+type UserGroupIterator struct {
+	pager
+	page []*UserGroup
+}
+
+// Next returns the next group, or ErrIteratorDone if there are no more groups
+func (it *UserGroupIterator) Next() (*UserGroup, error) {
+	i, err := it.next()
+	if err != nil {
+		return nil, err
+	}
+	return it.page[i], nil
+}
+
+// ClientIterator iterates over clients
+type ClientIterator struct {
+	pager
+	page []*Client
+}
+
+// Next returns the next client, or ErrIteratorDone if there are no more clients
+func (it *ClientIterator) Next() (*Client, error) {
+	i, err := it.next()
+	if err != nil {
+		return nil, err
+	}
+	return it.page[i], nil
+}
+
+// RoleIterator iterates over roles
+type RoleIterator struct {
+	pager
+	page []*Role
+}
+
+// Next returns the next role, or ErrIteratorDone if there are no more roles
+func (it *RoleIterator) Next() (*Role, error) {
+	i, err := it.next()
+	if err != nil {
+		return nil, err
+	}
+	return it.page[i], nil
+}
+
+// UserSessionIterator iterates over user sessions
+type UserSessionIterator struct {
+	pager
+	page []*UserSessionRepresentation
+}
+
+// Next returns the next session, or ErrIteratorDone if there are no more sessions
+func (it *UserSessionIterator) Next() (*UserSessionRepresentation, error) {
+	i, err := it.next()
+	if err != nil {
+		return nil, err
+	}
+	return it.page[i], nil
+}
+
+// IterateUsers returns an iterator over the users, which requests them page by page.
+// First and Max of the params limit the users of the iterator
+func (client *gocloak) IterateUsers(ctx context.Context, token string, realm string, params GetUsersParams) *UserIterator {
+	it := &UserIterator{}
+	it.pager = newPager(params.First, params.Max, func(first, max int) (int, error) {
+		params.First, params.Max = &first, &max
+		page, err := client.GetUsers(ctx, token, realm, params)
+		it.page = page
+		return len(page), err
+	})
+	return it
+}
+
+// IterateGroups returns an iterator over the groups, which requests them page by page.
+// First and Max of the params limit the groups of the iterator
+func (client *gocloak) IterateGroups(ctx context.Context, token string, realm string, params GetGroupsParams) *GroupIterator {
+	it := &GroupIterator{}
+	it.pager = newPager(params.First, params.Max, func(first, max int) (int, error) {
+		params.First, params.Max = &first, &max
+		page, err := client.GetGroups(ctx, token, realm, params)
+		it.page = page
+		return len(page), err
+	})
+	return it
+}
+
+// IterateUserGroups returns an iterator over the groups of the user, which requests them page by page.
+// First and Max of the params limit the groups of the iterator
+func (client *gocloak) IterateUserGroups(ctx context.Context, token string, realm string, userID string, params GetUserGroupsParams) *UserGroupIterator {
+	it := &UserGroupIterator{}
+	it.pager = newPager(params.First, params.Max, func(first, max int) (int, error) {
+		params.First, params.Max = &first, &max
+		page, err := client.GetUserGroups(ctx, token, realm, userID, params)
+		it.page = page
+		return len(page), err
+	})
+	return it
+}
+
+// IterateGroupMembers returns an iterator over the members of the group, which requests them page by page.
+// First and Max of the params limit the members of the iterator
+func (client *gocloak) IterateGroupMembers(ctx context.Context, token string, realm string, groupID string, params GetGroupsParams) *UserIterator {
+	it := &UserIterator{}
+	it.pager = newPager(params.First, params.Max, func(first, max int) (int, error) {
+		params.First, params.Max = &first, &max
+		page, err := client.GetGroupMembers(ctx, token, realm, groupID, params)
+		it.page = page
+		return len(page), err
+	})
+	return it
+}
+
+// IterateClients returns an iterator over the clients, which requests them page by page.
+// First and Max of the params limit the clients of the iterator
+func (client *gocloak) IterateClients(ctx context.Context, token string, realm string, params GetClientsParams) *ClientIterator {
+	it := &ClientIterator{}
+	it.pager = newPager(params.First, params.Max, func(first, max int) (int, error) {
+		params.First, params.Max = &first, &max
+		page, err := client.GetClients(ctx, token, realm, params)
+		it.page = page
+		return len(page), err
+	})
+	return it
+}
+
+// IterateUsersByRoleName returns an iterator over the users with the realm role, which requests them page by page.
+// First and Max of the params limit the users of the iterator
+func (client *gocloak) IterateUsersByRoleName(ctx context.Context, token string, realm string, roleName string, params GetUsersByRoleParams) *UserIterator {
+	it := &UserIterator{}
+	it.pager = newPager(params.First, params.Max, func(first, max int) (int, error) {
+		params.First, params.Max = &first, &max
+		page, err := client.GetUsersByRoleName(ctx, token, realm, roleName, params)
+		it.page = page
+		return len(page), err
+	})
+	return it
+}
+
+// IterateRealmRoles returns an iterator over the realm roles, which requests them page by page.
+// First and Max of the params limit the roles of the iterator
+func (client *gocloak) IterateRealmRoles(ctx context.Context, token string, realm string, params GetRoleParams) *RoleIterator {
+	it := &RoleIterator{}
+	it.pager = newPager(params.First, params.Max, func(first, max int) (int, error) {
+		params.First, params.Max = &first, &max
+		page, err := client.GetRealmRoles(ctx, token, realm, params)
+		it.page = page
+		return len(page), err
+	})
+	return it
+}
+
+// IterateClientRoles returns an iterator over the roles of the client, which requests them page by page.
+// First and Max of the params limit the roles of the iterator
+func (client *gocloak) IterateClientRoles(ctx context.Context, token string, realm string, clientID string, params GetRoleParams) *RoleIterator {
+	it := &RoleIterator{}
+	it.pager = newPager(params.First, params.Max, func(first, max int) (int, error) {
+		params.First, params.Max = &first, &max
+		page, err := client.GetClientRoles(ctx, token, realm, clientID, params)
+		it.page = page
+		return len(page), err
+	})
+	return it
+}
+
+// IterateClientUserSessions returns an iterator over the user sessions of the client, which requests them page by page.
+// First and Max of the params limit the sessions of the iterator
+func (client *gocloak) IterateClientUserSessions(ctx context.Context, token, realm, clientID string, params GetClientUserSessionsParams) *UserSessionIterator {
+	it := &UserSessionIterator{}
+	it.pager = newPager(params.First, params.Max, func(first, max int) (int, error) {
+		params.First, params.Max = &first, &max
+		page, err := client.GetClientUserSessions(ctx, token, realm, clientID, params)
+		it.page = page
+		return len(page), err
+	})
+	return it
+}
+
+// IterateClientOfflineSessions returns an iterator over the offline sessions of the client, which requests them page by page.
+// First and Max of the params limit the sessions of the iterator
+func (client *gocloak) IterateClientOfflineSessions(ctx context.Context, token, realm, clientID string, params GetClientUserSessionsParams) *UserSessionIterator {
+	it := &UserSessionIterator{}
+	it.pager = newPager(params.First, params.Max, func(first, max int) (int, error) {
+		params.First, params.Max = &first, &max
+		page, err := client.GetClientOfflineSessions(ctx, token, realm, clientID, params)
+		it.page = page
+		return len(page), err
+	})
+	return it
+}
@@ -0,0 +1,193 @@
+package gocloak
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pagedServer serves count results of the path with the first and max query parameters and records the pages
+func pagedServer(t *testing.T, path string, count int) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, path, r.URL.Path)
+		first, err := strconv.Atoi(r.URL.Query().Get("first"))
+		assert.NoError(t, err)
+		max, err := strconv.Atoi(r.URL.Query().Get("max"))
+		assert.NoError(t, err)
+		mu.Lock()
+		pages = append(pages, fmt.Sprintf("%d+%d", first, max))
+		mu.Unlock()
+
+		results := []map[string]string{}
+		for i := first; i < first+max && i < count; i++ {
+			results = append(results, map[string]string{"id": strconv.Itoa(i)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		assert.NoError(t, json.NewEncoder(w).Encode(results))
+	}))
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return pages
+	}
+}
+
+func TestIterateUsers(t *testing.T) {
+	t.Parallel()
+	server, pages := pagedServer(t, "/auth/admin/realms/realm/users", 5)
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	users := client.IterateUsers(context.Background(), "token", "realm", GetUsersParams{Search: StringP("name")})
+	users.SetPageSize(2)
+	var ids []string
+	for {
+		user, err := users.Next()
+		if err == ErrIteratorDone {
+			break
+		}
+		assert.NoError(t, err)
+		ids = append(ids, PString(user.ID))
+	}
+	assert.Equal(t, []string{"0", "1", "2", "3", "4"}, ids)
+	assert.Equal(t, []string{"0+2", "2+2", "4+2"}, pages())
+
+	_, err := users.Next()
+	assert.Equal(t, ErrIteratorDone, err)
+	assert.Len(t, pages(), 3)
+}
+
+func TestIterateUsers_FirstMax(t *testing.T) {
+	t.Parallel()
+	server, pages := pagedServer(t, "/auth/admin/realms/realm/roles/role/users", 100)
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	users := client.IterateUsersByRoleName(context.Background(), "token", "realm", "role", GetUsersByRoleParams{
+		First: IntP(10),
+		Max:   IntP(5),
+	})
+	users.SetPageSize(3)
+	var ids []string
+	for {
+		user, err := users.Next()
+		if err == ErrIteratorDone {
+			break
+		}
+		assert.NoError(t, err)
+		ids = append(ids, PString(user.ID))
+	}
+	assert.Equal(t, []string{"10", "11", "12", "13", "14"}, ids)
+	assert.Equal(t, []string{"10+3", "13+2"}, pages())
+}
+
+func TestIterateClientRoles_StopEarly(t *testing.T) {
+	t.Parallel()
+	server, pages := pagedServer(t, "/auth/admin/realms/realm/clients/client/roles", 1000)
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	roles := client.IterateClientRoles(context.Background(), "token", "realm", "client", GetRoleParams{})
+	for i := 0; i < 101; i++ {
+		role, err := roles.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(i), PString(role.ID))
+	}
+	assert.Equal(t, []string{"0+100", "100+100"}, pages())
+}
+
+func TestIterate_Endpoints(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	for path, next := range map[string]func(client GoCloak) (interface{}, error){
+		"/auth/admin/realms/realm/groups": func(client GoCloak) (interface{}, error) {
+			return client.IterateGroups(ctx, "token", "realm", GetGroupsParams{}).Next()
+		},
+		"/auth/admin/realms/realm/users/user/groups": func(client GoCloak) (interface{}, error) {
+			return client.IterateUserGroups(ctx, "token", "realm", "user", GetUserGroupsParams{}).Next()
+		},
+		"/auth/admin/realms/realm/groups/group/members": func(client GoCloak) (interface{}, error) {
+			return client.IterateGroupMembers(ctx, "token", "realm", "group", GetGroupsParams{}).Next()
+		},
+		"/auth/admin/realms/realm/clients": func(client GoCloak) (interface{}, error) {
+			return client.IterateClients(ctx, "token", "realm", GetClientsParams{}).Next()
+		},
+		"/auth/admin/realms/realm/roles": func(client GoCloak) (interface{}, error) {
+			return client.IterateRealmRoles(ctx, "token", "realm", GetRoleParams{}).Next()
+		},
+		"/auth/admin/realms/realm/clients/client/user-sessions": func(client GoCloak) (interface{}, error) {
+			return client.IterateClientUserSessions(ctx, "token", "realm", "client", GetClientUserSessionsParams{}).Next()
+		},
+		"/auth/admin/realms/realm/clients/client/offline-sessions": func(client GoCloak) (interface{}, error) {
+			return client.IterateClientOfflineSessions(ctx, "token", "realm", "client", GetClientUserSessionsParams{}).Next()
+		},
+	} {
+		server, pages := pagedServer(t, path, 1)
+		result, err := next(NewClient(server.URL))
+		assert.NoError(t, err, path)
+		assert.NotNil(t, result, path)
+		assert.Equal(t, []string{"0+100"}, pages(), path)
+		server.Close()
+	}
+}
+
+func TestGetOptionalQueryParams(t *testing.T) {
+	t.Parallel()
+	queries := make(chan url.Values, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	_, err := client.GetRealmRoles(context.Background(), "token", "realm")
+	assert.NoError(t, err)
+	assert.Empty(t, <-queries)
+
+	_, err = client.GetUserGroups(context.Background(), "token", "realm", "user", GetUserGroupsParams{
+		Search:              StringP("admins"),
+		BriefRepresentation: BoolP(false),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"search": {"admins"}, "briefRepresentation": {"false"}}, <-queries)
+}
+
+func TestIterateGroups_Error(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":"forbidden"}`))
+	}))
+	defer server.Close()
+
+	groups := NewClient(server.URL).IterateGroups(context.Background(), "token", "realm", GetGroupsParams{})
+	_, err := groups.Next()
+	assert.True(t, IsForbidden(err))
+	_, err = groups.Next()
+	assert.True(t, IsForbidden(err))
+}
+
+func TestInstrumentation_Iterator(t *testing.T) {
+	t.Parallel()
+	server, _ := pagedServer(t, "/auth/admin/realms/realm/users", 1)
+	defer server.Close()
+	instrumentation := &recordingInstrumentation{}
+
+	client := NewClient(server.URL, SetInstrumentation(instrumentation))
+	_, err := client.IterateUsers(context.Background(), "token", "realm", GetUsersParams{}).Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"GetUsers"}, instrumentation.operations)
+}
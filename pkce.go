@@ -0,0 +1,49 @@
+package gocloak
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// PKCE holds the code verifier and the code challenge of the Proof Key for Code Exchange (RFC 7636)
+type PKCE struct {
+	// CodeVerifier is kept by the client and sent with the code exchange
+	CodeVerifier string
+	// CodeChallenge is sent with the authorization request
+	CodeChallenge string
+	// CodeChallengeMethod is always S256
+	CodeChallengeMethod string
+}
+
+// NewPKCE creates a random code verifier and its S256 code challenge
+func NewPKCE() (*PKCE, error) {
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	return &PKCE{
+		CodeVerifier:        verifier,
+		CodeChallenge:       CodeChallengeS256(verifier),
+		CodeChallengeMethod: "S256",
+	}, nil
+}
+
+// CodeChallengeS256 returns the S256 code challenge of the code verifier
+func CodeChallengeS256(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// NewState creates a random value for the state or the nonce parameter of the authorization request
+func NewState() (string, error) {
+	return randomString(32)
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
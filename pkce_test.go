@@ -0,0 +1,132 @@
+package gocloak
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/kkovarik/gocloak/pkg/jwx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeChallengeS256(t *testing.T) {
+	t.Parallel()
+	// the example of RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", CodeChallengeS256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func TestNewPKCE(t *testing.T) {
+	t.Parallel()
+	pkce, err := NewPKCE()
+	assert.NoError(t, err)
+	assert.Len(t, pkce.CodeVerifier, 43)
+	assert.Equal(t, CodeChallengeS256(pkce.CodeVerifier), pkce.CodeChallenge)
+	assert.Equal(t, "S256", pkce.CodeChallengeMethod)
+
+	other, err := NewPKCE()
+	assert.NoError(t, err)
+	assert.NotEqual(t, pkce.CodeVerifier, other.CodeVerifier)
+}
+
+func TestGocloak_GetAuthCodeURL(t *testing.T) {
+	t.Parallel()
+	client := NewClient("https://keycloak.example.com")
+	authURL, err := client.GetAuthCodeURL("realm", AuthCodeURLOptions{
+		ClientID:            StringP("my-app"),
+		RedirectURI:         StringP("https://app.example.com/callback"),
+		State:               StringP("state"),
+		Nonce:               StringP("nonce"),
+		CodeChallenge:       StringP("challenge"),
+		CodeChallengeMethod: StringP("S256"),
+		Prompt:              StringP("login"),
+		LoginHint:           StringP("john"),
+	})
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "/auth/realms/realm/protocol/openid-connect/auth", parsed.Path)
+	query := parsed.Query()
+	assert.Equal(t, "my-app", query.Get("client_id"))
+	assert.Equal(t, "https://app.example.com/callback", query.Get("redirect_uri"))
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "openid", query.Get("scope"))
+	assert.Equal(t, "state", query.Get("state"))
+	assert.Equal(t, "nonce", query.Get("nonce"))
+	assert.Equal(t, "challenge", query.Get("code_challenge"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, "login", query.Get("prompt"))
+	assert.Equal(t, "john", query.Get("login_hint"))
+
+	authURL, err = client.GetAuthCodeURL("realm", AuthCodeURLOptions{ClientID: StringP("my-app")})
+	assert.NoError(t, err)
+	parsed, err = url.Parse(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"client_id":     {"my-app"},
+		"response_type": {"code"},
+		"scope":         {"openid"},
+	}, parsed.Query())
+
+	_, err = client.GetAuthCodeURL("realm", AuthCodeURLOptions{RedirectURI: StringP("https://app.example.com/callback")})
+	assert.Error(t, err)
+}
+
+func TestGocloak_ExchangeCode(t *testing.T) {
+	t.Parallel()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/auth/realms/realm/protocol/openid-connect/certs":
+			_ = json.NewEncoder(w).Encode(CertResponse{Keys: []*CertResponseKey{rsaCertKey("kid", &key.PublicKey)}})
+		case "/auth/realms/realm/protocol/openid-connect/token":
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "authorization_code", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "code", r.PostForm.Get("code"))
+			assert.Equal(t, "verifier", r.PostForm.Get("code_verifier"))
+			assert.Equal(t, "https://app.example.com/callback", r.PostForm.Get("redirect_uri"))
+
+			idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+				"exp":   float64(time.Now().Add(time.Minute).Unix()),
+				"iss":   server.URL + "/auth/realms/realm",
+				"aud":   "my-app",
+				"typ":   "ID",
+				"nonce": "nonce",
+			})
+			idToken.Header["kid"] = "kid"
+			signed, err := idToken.SignedString(key)
+			assert.NoError(t, err)
+			_ = json.NewEncoder(w).Encode(JWT{AccessToken: "access", IDToken: signed})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	options := TokenOptions{
+		ClientID:     StringP("my-app"),
+		Code:         StringP("code"),
+		RedirectURI:  StringP("https://app.example.com/callback"),
+		CodeVerifier: StringP("verifier"),
+	}
+	token, err := client.ExchangeCode(context.Background(), "realm", options, "nonce")
+	assert.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken)
+
+	_, err = client.ExchangeCode(context.Background(), "realm", options, "replayed")
+	var nonceError *jwx.InvalidNonceError
+	assert.True(t, errors.As(err, &nonceError), "unexpected error: %v", err)
+}
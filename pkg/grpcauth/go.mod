@@ -0,0 +1,23 @@
+module github.com/kkovarik/gocloak/pkg/grpcauth
+
+// grpc requires go 1.25, the root module supports older versions
+go 1.25.0
+
+require (
+	github.com/dgrijalva/jwt-go v3.2.0+incompatible
+	github.com/kkovarik/gocloak v0.0.0-20261016151651-67dafe95d729
+	github.com/stretchr/testify v1.11.1
+	google.golang.org/grpc v1.81.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-resty/resty/v2 v2.0.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.51.0 // indirect
+	golang.org/x/sys v0.42.0 // indirect
+	golang.org/x/text v0.34.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260226221140-a57be14db171 // indirect
+	google.golang.org/protobuf v1.36.11 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
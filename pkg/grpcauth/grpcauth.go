@@ -0,0 +1,220 @@
+// Package grpcauth authenticates gRPC calls with access tokens issued by keycloak
+package grpcauth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/kkovarik/gocloak"
+	"github.com/kkovarik/gocloak/pkg/jwx"
+	"github.com/kkovarik/gocloak/pkg/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const authorizationKey = "authorization"
+
+// The messages of the status errors do not contain the cause, so callers learn nothing about the verification
+const (
+	invalidTokenMessage = "invalid access token"
+	unavailableMessage  = "the access token cannot be verified"
+)
+
+// Option configures the Authenticator
+type Option func(*Authenticator)
+
+// WithValidation sets the checks applied to the claims of the token
+func WithValidation(options ...jwx.ValidationOption) Option {
+	return func(a *Authenticator) {
+		a.validation = append(a.validation, options...)
+	}
+}
+
+// WithPolicy sets the policy the calls of methods without an own policy must satisfy
+func WithPolicy(policy jwx.Policy) Option {
+	return func(a *Authenticator) {
+		a.policy = policy
+	}
+}
+
+// WithMethodPolicy sets the policy the calls of the given method, e.g. "/package.Service/Method", must satisfy
+func WithMethodPolicy(fullMethod string, policy jwx.Policy) Option {
+	return func(a *Authenticator) {
+		a.methodPolicies[fullMethod] = policy
+	}
+}
+
+// WithMethodRoles allows the calls of the given method to users with at least one of the given roles
+func WithMethodRoles(fullMethod string, roles ...jwx.Role) Option {
+	return WithMethodPolicy(fullMethod, jwx.RequireAnyRole(roles...))
+}
+
+// WithPublicMethods lets calls of the given methods through without a token, e.g. health checks
+func WithPublicMethods(fullMethods ...string) Option {
+	return func(a *Authenticator) {
+		for _, method := range fullMethods {
+			a.publicMethods[method] = true
+		}
+	}
+}
+
+// Authenticator verifies the bearer token of gRPC calls and puts its claims into the context
+type Authenticator struct {
+	client         gocloak.GoCloak
+	realm          string
+	validation     []jwx.ValidationOption
+	policy         jwx.Policy
+	methodPolicies map[string]jwx.Policy
+	publicMethods  map[string]bool
+}
+
+// New creates an Authenticator verifying tokens of the given realm
+func New(client gocloak.GoCloak, realm string, options ...Option) *Authenticator {
+	a := &Authenticator{
+		client:         client,
+		realm:          realm,
+		methodPolicies: make(map[string]jwx.Policy),
+		publicMethods:  make(map[string]bool),
+	}
+	for _, option := range options {
+		option(a)
+	}
+	return a
+}
+
+// Authenticate verifies the token of the call and returns a context carrying the token and its claims,
+// which can be read with middleware.ClaimsFromContext and middleware.TokenFromContext
+func (a *Authenticator) Authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	if a.publicMethods[fullMethod] {
+		return ctx, nil
+	}
+
+	token := tokenFromMetadata(ctx)
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, middleware.ErrMissingToken.Error())
+	}
+
+	claims := &jwx.Claims{}
+	if _, err := a.client.DecodeAccessTokenCustomClaims(ctx, token, a.realm, claims, a.validation...); err != nil {
+		return nil, verificationError(ctx, err)
+	}
+
+	policy, ok := a.methodPolicies[fullMethod]
+	if !ok {
+		policy = a.policy
+	}
+	if policy != nil && !policy.Allowed(claims) {
+		return nil, status.Error(codes.PermissionDenied, middleware.ErrForbidden.Error())
+	}
+
+	return middleware.NewContext(ctx, token, claims), nil
+}
+
+// verificationError returns Canceled or DeadlineExceeded if the context of the call is done,
+// Unavailable if keycloak could not be reached to verify the token, e.g. to fetch the certs,
+// and Unauthenticated if the token is invalid
+func verificationError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	if isUnavailable(err) {
+		return status.Error(codes.Unavailable, unavailableMessage)
+	}
+	return status.Error(codes.Unauthenticated, invalidTokenMessage)
+}
+
+func isUnavailable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) || errors.Is(err, gocloak.ErrRateLimited) {
+		return true
+	}
+	var netError net.Error
+	if errors.As(err, &netError) {
+		return true
+	}
+	var apiError *gocloak.APIError
+	return errors.As(err, &apiError) && apiError.Code >= 500
+}
+
+// UnaryServerInterceptor returns a server interceptor authenticating unary calls
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.Authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a server interceptor authenticating streaming calls
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.Authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func tokenFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, value := range md.Get(authorizationKey) {
+		if len(value) > 7 && strings.EqualFold(value[:7], "bearer ") {
+			return strings.TrimSpace(value[7:])
+		}
+	}
+	return ""
+}
+
+// UnaryClientInterceptor returns a client interceptor sending a token of the token source with every unary call
+func UnaryClientInterceptor(tokenSource gocloak.TokenSource) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := withToken(ctx, tokenSource)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor returns a client interceptor sending a token of the token source with every streaming call
+func StreamClientInterceptor(tokenSource gocloak.TokenSource) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := withToken(ctx, tokenSource)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+func withToken(ctx context.Context, tokenSource gocloak.TokenSource) (context.Context, error) {
+	token, err := tokenSource.AccessToken(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		if isUnavailable(err) {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return metadata.AppendToOutgoingContext(ctx, authorizationKey, "Bearer "+token), nil
+}
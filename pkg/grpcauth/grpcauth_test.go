@@ -0,0 +1,189 @@
+package grpcauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/kkovarik/gocloak"
+	"github.com/kkovarik/gocloak/pkg/jwx"
+	"github.com/kkovarik/gocloak/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testRealm  = "gocloak"
+	testSecret = "realm-secret"
+)
+
+func newTestClient() gocloak.GoCloak {
+	// HMAC signed tokens are verified with the realm secret, so no keycloak is needed
+	client := gocloak.NewClient("http://127.0.0.1:1")
+	client.SetRealmSecret(testRealm, []byte(testSecret))
+	return client
+}
+
+func validToken(t *testing.T) string {
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp":                float64(time.Now().Add(time.Minute).Unix()),
+		"preferred_username": "john",
+		"realm_access":       map[string]interface{}{"roles": []string{"user"}},
+	}).SignedString([]byte(testSecret))
+	assert.NoError(t, err)
+	return signed
+}
+
+func incomingContext(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func claimsHandler(ctx context.Context, req interface{}) (interface{}, error) {
+	claims, ok := middleware.ClaimsFromContext(ctx)
+	if !ok {
+		return nil, errors.New("no claims in the context")
+	}
+	return claims.PreferredUsername, nil
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Parallel()
+	interceptor := New(newTestClient(), testRealm,
+		WithMethodRoles("/test.Service/Admin", jwx.RealmRole("admin")),
+		WithPublicMethods("/grpc.health.v1.Health/Check"),
+	).UnaryServerInterceptor()
+	info := func(method string) *grpc.UnaryServerInfo {
+		return &grpc.UnaryServerInfo{FullMethod: method}
+	}
+
+	resp, err := interceptor(incomingContext(validToken(t)), nil, info("/test.Service/Get"), claimsHandler)
+	assert.NoError(t, err)
+	assert.Equal(t, "john", resp)
+
+	_, err = interceptor(context.Background(), nil, info("/test.Service/Get"), claimsHandler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = interceptor(incomingContext("invalid"), nil, info("/test.Service/Get"), claimsHandler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = interceptor(incomingContext(validToken(t)), nil, info("/test.Service/Admin"), claimsHandler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	resp, err = interceptor(context.Background(), nil, info("/grpc.health.v1.Health/Check"), func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "SERVING", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "SERVING", resp)
+}
+
+func TestAuthenticate_KeycloakUnavailable(t *testing.T) {
+	t.Parallel()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"exp": float64(time.Now().Add(time.Minute).Unix())})
+	token.Header["kid"] = "kid"
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+
+	// the certs cannot be fetched from the closed port
+	client := gocloak.NewClient("http://127.0.0.1:1", gocloak.SetRetryPolicy(gocloak.RetryPolicy{}))
+	_, err = New(client, testRealm).Authenticate(incomingContext(signed), "/test.Service/Get")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, unavailableMessage, status.Convert(err).Message())
+
+	_, err = New(newTestClient(), testRealm).Authenticate(incomingContext("invalid"), "/test.Service/Get")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, invalidTokenMessage, status.Convert(err).Message())
+}
+
+func TestAuthenticate_ContextDone(t *testing.T) {
+	t.Parallel()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"exp": float64(time.Now().Add(time.Minute).Unix())})
+	token.Header["kid"] = "kid"
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+	authenticator := New(gocloak.NewClient("http://127.0.0.1:1"), testRealm)
+
+	ctx, cancel := context.WithCancel(incomingContext(signed))
+	cancel()
+	_, err = authenticator.Authenticate(ctx, "/test.Service/Get")
+	assert.Equal(t, codes.Canceled, status.Code(err))
+
+	ctx, cancel = context.WithDeadline(incomingContext(signed), time.Now())
+	defer cancel()
+	_, err = authenticator.Authenticate(ctx, "/test.Service/Get")
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	t.Parallel()
+	interceptor := New(newTestClient(), testRealm, WithPolicy(jwx.RequireRealmRole("user"))).StreamServerInterceptor()
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Service/Watch"}
+
+	err := interceptor(nil, &testServerStream{ctx: incomingContext(validToken(t))}, info, func(srv interface{}, stream grpc.ServerStream) error {
+		_, err := claimsHandler(stream.Context(), nil)
+		return err
+	})
+	assert.NoError(t, err)
+
+	err = interceptor(nil, &testServerStream{ctx: context.Background()}, info, func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+type staticTokenSource struct {
+	gocloak.TokenSource
+	token string
+	err   error
+}
+
+func (ts *staticTokenSource) AccessToken(ctx context.Context) (string, error) {
+	return ts.token, ts.err
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	t.Parallel()
+	interceptor := UnaryClientInterceptor(&staticTokenSource{token: "token"})
+	err := interceptor(context.Background(), "/test.Service/Get", nil, nil, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, ok := metadata.FromOutgoingContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"Bearer token"}, md.Get("authorization"))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	interceptor = UnaryClientInterceptor(&staticTokenSource{err: errors.New("login failed")})
+	err = interceptor(context.Background(), "/test.Service/Get", nil, nil, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		t.Error("the call must not be invoked without a token")
+		return nil
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	interceptor = UnaryClientInterceptor(&staticTokenSource{err: context.Canceled})
+	err = interceptor(ctx, "/test.Service/Get", nil, nil, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		t.Error("the call must not be invoked without a token")
+		return nil
+	})
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
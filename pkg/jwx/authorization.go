@@ -0,0 +1,172 @@
+package jwx
+
+import "strings"
+
+// Role is a realm role or, if ClientID is set, a role of the client
+type Role struct {
+	ClientID string
+	Name     string
+}
+
+// RealmRole returns the realm role with the given name
+func RealmRole(name string) Role {
+	return Role{Name: name}
+}
+
+// ClientRole returns the role with the given name of the client
+func ClientRole(clientID, name string) Role {
+	return Role{ClientID: clientID, Name: name}
+}
+
+func (r Role) String() string {
+	if r.ClientID == "" {
+		return r.Name
+	}
+	return r.ClientID + ":" + r.Name
+}
+
+// HasRealmRole returns true if the user has the given realm role
+func (c *Claims) HasRealmRole(role string) bool {
+	return containsAny(c.RealmAccess.Roles, []string{role})
+}
+
+// HasClientRole returns true if the user has the given role of the client
+func (c *Claims) HasClientRole(clientID, role string) bool {
+	return containsAny(c.ResourceAccess[clientID].Roles, []string{role})
+}
+
+// HasScope returns true if the token has been granted the given scope
+func (c *Claims) HasScope(scope string) bool {
+	return containsAny(strings.Fields(c.Scope), []string{scope})
+}
+
+// HasRole returns true if the user has the given realm or client role
+func (c *Claims) HasRole(role Role) bool {
+	if role.ClientID == "" {
+		return c.HasRealmRole(role.Name)
+	}
+	return c.HasClientRole(role.ClientID, role.Name)
+}
+
+// HasAnyRole returns true if the user has at least one of the given roles
+func (c *Claims) HasAnyRole(roles ...Role) bool {
+	for _, role := range roles {
+		if c.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllRoles returns true if the user has all the given roles
+func (c *Claims) HasAllRoles(roles ...Role) bool {
+	for _, role := range roles {
+		if !c.HasRole(role) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasPermission returns true if the requesting party token grants the scope of the resource,
+// which is matched by its ID or name. An empty scope matches any permission of the resource
+func (c *Claims) HasPermission(resource, scope string) bool {
+	if c.Authorization == nil {
+		return false
+	}
+	for _, permission := range c.Authorization.Permissions {
+		if permission.ResourceID != resource && permission.ResourceName != resource {
+			continue
+		}
+		if scope == "" || containsAny(permission.Scopes, []string{scope}) {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy decides whether the claims of a token grant access
+type Policy func(claims *Claims) bool
+
+// RequireRealmRole grants access to users with the given realm role
+func RequireRealmRole(role string) Policy {
+	return func(claims *Claims) bool {
+		return claims.HasRealmRole(role)
+	}
+}
+
+// RequireClientRole grants access to users with the given role of the client
+func RequireClientRole(clientID, role string) Policy {
+	return func(claims *Claims) bool {
+		return claims.HasClientRole(clientID, role)
+	}
+}
+
+// RequireScopes grants access to tokens with all the given scopes
+func RequireScopes(scopes ...string) Policy {
+	return func(claims *Claims) bool {
+		for _, scope := range scopes {
+			if !claims.HasScope(scope) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// RequirePermission grants access to requesting party tokens with the scope of the resource
+func RequirePermission(resource, scope string) Policy {
+	return func(claims *Claims) bool {
+		return claims.HasPermission(resource, scope)
+	}
+}
+
+// RequireAnyRole grants access to users with at least one of the given roles
+func RequireAnyRole(roles ...Role) Policy {
+	return func(claims *Claims) bool {
+		return claims.HasAnyRole(roles...)
+	}
+}
+
+// RequireAllRoles grants access to users with all the given roles
+func RequireAllRoles(roles ...Role) Policy {
+	return func(claims *Claims) bool {
+		return claims.HasAllRoles(roles...)
+	}
+}
+
+// And grants access if all the given policies grant access
+func And(policies ...Policy) Policy {
+	return func(claims *Claims) bool {
+		for _, policy := range policies {
+			if !policy(claims) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or grants access if at least one of the given policies grants access
+func Or(policies ...Policy) Policy {
+	return func(claims *Claims) bool {
+		for _, policy := range policies {
+			if policy(claims) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not grants access if the given policy denies access
+func Not(policy Policy) Policy {
+	return func(claims *Claims) bool {
+		return !policy(claims)
+	}
+}
+
+// Allowed returns true if the policy grants access, a nil policy or nil claims deny access
+func (p Policy) Allowed(claims *Claims) bool {
+	return p != nil && claims != nil && p(claims)
+}
@@ -0,0 +1,77 @@
+package jwx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testAuthorizationClaims(t *testing.T) *Claims {
+	claims := &Claims{}
+	err := json.Unmarshal([]byte(`{
+		"realm_access": {"roles": ["offline_access", "user"]},
+		"resource_access": {
+			"my-client": {"roles": ["reader", "writer"]},
+			"account": {"roles": ["manage-account"]}
+		},
+		"scope": "openid email profile"
+	}`), claims)
+	assert.NoError(t, err)
+	return claims
+}
+
+func TestClaims_Roles(t *testing.T) {
+	t.Parallel()
+	claims := testAuthorizationClaims(t)
+
+	assert.True(t, claims.HasRealmRole("user"))
+	assert.False(t, claims.HasRealmRole("admin"))
+	assert.True(t, claims.HasClientRole("my-client", "writer"))
+	assert.False(t, claims.HasClientRole("my-client", "admin"))
+	assert.False(t, claims.HasClientRole("other-client", "writer"))
+	assert.True(t, claims.HasScope("email"))
+	assert.False(t, claims.HasScope("offline_access"))
+
+	assert.True(t, claims.HasAnyRole(RealmRole("admin"), ClientRole("account", "manage-account")))
+	assert.False(t, claims.HasAnyRole(RealmRole("admin"), ClientRole("account", "view-profile")))
+	assert.True(t, claims.HasAllRoles(RealmRole("user"), ClientRole("my-client", "reader")))
+	assert.False(t, claims.HasAllRoles(RealmRole("user"), ClientRole("my-client", "admin")))
+}
+
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+	claims := testAuthorizationClaims(t)
+
+	policy := And(
+		RequireScopes("openid"),
+		Or(RequireRealmRole("admin"), RequireClientRole("my-client", "writer")),
+		Not(RequireAnyRole(ClientRole("my-client", "banned"))),
+	)
+	assert.True(t, policy.Allowed(claims))
+	assert.False(t, And(policy, RequireAllRoles(RealmRole("admin"))).Allowed(claims))
+	assert.False(t, policy.Allowed(nil))
+	assert.False(t, Policy(nil).Allowed(claims))
+}
+
+func TestClaims_Permissions(t *testing.T) {
+	t.Parallel()
+	claims := &Claims{}
+	err := json.Unmarshal([]byte(`{
+		"authorization": {
+			"permissions": [
+				{"rsid": "7f3a", "rsname": "Orders", "scopes": ["view", "edit"]},
+				{"rsid": "9c1e", "rsname": "Invoices"}
+			]
+		}
+	}`), claims)
+	assert.NoError(t, err)
+
+	assert.True(t, claims.HasPermission("Orders", "edit"))
+	assert.True(t, claims.HasPermission("7f3a", "view"))
+	assert.False(t, claims.HasPermission("Orders", "delete"))
+	assert.True(t, claims.HasPermission("Invoices", ""))
+	assert.False(t, claims.HasPermission("Customers", ""))
+	assert.True(t, RequirePermission("Orders", "view").Allowed(claims))
+	assert.False(t, RequirePermission("Orders", "view").Allowed(&Claims{}))
+}
@@ -0,0 +1,81 @@
+package jwx
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TokenExpiredError is returned when the exp claim of the token is in the past
+type TokenExpiredError struct {
+	ExpiredAt time.Time
+}
+
+func (e *TokenExpiredError) Error() string {
+	return fmt.Sprintf("token is expired since %s", e.ExpiredAt.UTC().Format(time.RFC3339))
+}
+
+// TokenNotValidYetError is returned when the nbf or iat claim of the token is in the future
+type TokenNotValidYetError struct {
+	NotBefore time.Time
+}
+
+func (e *TokenNotValidYetError) Error() string {
+	return fmt.Sprintf("token is not valid before %s", e.NotBefore.UTC().Format(time.RFC3339))
+}
+
+// InvalidIssuerError is returned when the iss claim does not match the expected issuer
+type InvalidIssuerError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *InvalidIssuerError) Error() string {
+	return fmt.Sprintf("invalid issuer: expected %q, got %q", e.Expected, e.Actual)
+}
+
+// InvalidAudienceError is returned when the aud claim contains none of the expected audiences
+type InvalidAudienceError struct {
+	Expected []string
+	Actual   []string
+}
+
+func (e *InvalidAudienceError) Error() string {
+	return fmt.Sprintf("invalid audience: expected one of [%s], got [%s]", strings.Join(e.Expected, ", "), strings.Join(e.Actual, ", "))
+}
+
+// InvalidAuthorizedPartyError is returned when the azp claim does not match the expected client
+type InvalidAuthorizedPartyError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *InvalidAuthorizedPartyError) Error() string {
+	return fmt.Sprintf("invalid authorized party: expected %q, got %q", e.Expected, e.Actual)
+}
+
+// MissingScopesError is returned when the scope claim lacks some of the required scopes
+type MissingScopesError struct {
+	Missing []string
+}
+
+func (e *MissingScopesError) Error() string {
+	return fmt.Sprintf("missing scopes: %s", strings.Join(e.Missing, ", "))
+}
+
+// InvalidTokenTypeError is returned when the typ claim is none of the accepted token types
+type InvalidTokenTypeError struct {
+	Expected []string
+	Actual   string
+}
+
+func (e *InvalidTokenTypeError) Error() string {
+	return fmt.Sprintf("invalid token type: expected one of [%s], got %q", strings.Join(e.Expected, ", "), e.Actual)
+}
+
+// InvalidNonceError is returned when the nonce claim does not match the nonce of the authorization request
+type InvalidNonceError struct{}
+
+func (e *InvalidNonceError) Error() string {
+	return "invalid nonce"
+}
@@ -2,10 +2,13 @@ package jwx
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
@@ -17,7 +20,7 @@ import (
 func DecodeAccessTokenHeader(token string) (*DecodedAccessTokenHeader, error) {
 	token = strings.Replace(token, "Bearer ", "", 1)
 	headerString := strings.Split(token, ".")
-	decodedData, err := base64.RawStdEncoding.DecodeString(headerString[0])
+	decodedData, err := base64.RawURLEncoding.DecodeString(headerString[0])
 	if err != nil {
 		return nil, err
 	}
@@ -32,6 +35,9 @@ func DecodeAccessTokenHeader(token string) (*DecodedAccessTokenHeader, error) {
 }
 
 func decodePublicKey(e, n *string) (*rsa.PublicKey, error) {
+	if e == nil || n == nil {
+		return nil, errors.New("the RSA key must contain e and n")
+	}
 	decN, err := base64.RawURLEncoding.DecodeString(*n)
 	if err != nil {
 		return nil, err
@@ -61,39 +67,102 @@ func decodePublicKey(e, n *string) (*rsa.PublicKey, error) {
 	return &pKey, nil
 }
 
-// DecodeAccessToken currently only supports RSA - sorry for that
-func DecodeAccessToken(accessToken string, e, n *string) (*jwt.Token, *jwt.MapClaims, error) {
-	rsaPublicKey, err := decodePublicKey(e, n)
+func decodeECDSAPublicKey(x, y, crv *string) (*ecdsa.PublicKey, error) {
+	if x == nil || y == nil || crv == nil {
+		return nil, errors.New("the EC key must contain x, y and crv")
+	}
+
+	var curve elliptic.Curve
+	switch *crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve: %s", *crv)
+	}
+
+	decX, err := base64.RawURLEncoding.DecodeString(*x)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	decY, err := base64.RawURLEncoding.DecodeString(*y)
+	if err != nil {
+		return nil, err
 	}
 
-	claims := &jwt.MapClaims{}
-	token2, err := jwt.ParseWithClaims(accessToken, claims, func(token *jwt.Token) (interface{}, error) {
-		// Don't forget to validate the alg is what you expect:
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return rsaPublicKey, nil
-	})
+	pKey := ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(decX),
+		Y:     new(big.Int).SetBytes(decY),
+	}
+	if !curve.IsOnCurve(pKey.X, pKey.Y) {
+		return nil, errors.New("the EC key is not on the curve " + *crv)
+	}
+	return &pKey, nil
+}
 
+// DecodeAccessToken decodes the accessToken signed with an RSA or RSA-PSS key
+func DecodeAccessToken(accessToken string, e, n *string, options ...ValidationOption) (*jwt.Token, *jwt.MapClaims, error) {
+	claims := &jwt.MapClaims{}
+	token2, err := DecodeAccessTokenRSACustomClaims(accessToken, e, n, claims, options...)
 	return token2, claims, err
 }
 
-// DecodeAccessTokenCustomClaims currently only supports RSA - sorry for that
-func DecodeAccessTokenCustomClaims(accessToken string, e, n *string, customClaims jwt.Claims) (*jwt.Token, error) {
+// DecodeAccessTokenCustomClaims decodes the accessToken signed with an RSA or RSA-PSS key and fills the given claims
+func DecodeAccessTokenCustomClaims(accessToken string, e, n *string, customClaims jwt.Claims, options ...ValidationOption) (*jwt.Token, error) {
+	return DecodeAccessTokenRSACustomClaims(accessToken, e, n, customClaims, options...)
+}
+
+// DecodeAccessTokenRSACustomClaims decodes the accessToken signed with an RSA (RS*) or RSA-PSS (PS*) key
+func DecodeAccessTokenRSACustomClaims(accessToken string, e, n *string, customClaims jwt.Claims, options ...ValidationOption) (*jwt.Token, error) {
 	rsaPublicKey, err := decodePublicKey(e, n)
 	if err != nil {
 		return nil, err
 	}
 
-	token2, err := jwt.ParseWithClaims(accessToken, customClaims, func(token *jwt.Token) (interface{}, error) {
+	return parseWithClaims(accessToken, customClaims, func(token *jwt.Token) (interface{}, error) {
 		// Don't forget to validate the alg is what you expect:
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return rsaPublicKey, nil
+		}
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}, options)
+}
+
+// DecodeAccessTokenECDSACustomClaims decodes the accessToken signed with an EC key on the P-256, P-384 or P-521 curve
+func DecodeAccessTokenECDSACustomClaims(accessToken string, x, y, crv *string, customClaims jwt.Claims, options ...ValidationOption) (*jwt.Token, error) {
+	ecdsaPublicKey, err := decodeECDSAPublicKey(x, y, crv)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseWithClaims(accessToken, customClaims, func(token *jwt.Token) (interface{}, error) {
+		method, ok := token.Method.(*jwt.SigningMethodECDSA)
+		if !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return rsaPublicKey, nil
-	})
+		// ES256 must be signed with a P-256 key, ES384 with a P-384 key and ES512 with a P-521 key
+		if method.CurveBits != ecdsaPublicKey.Curve.Params().BitSize {
+			return nil, fmt.Errorf("signing method %v does not match the curve %s", token.Header["alg"], *crv)
+		}
+		return ecdsaPublicKey, nil
+	}, options)
+}
 
-	return token2, err
+// DecodeAccessTokenHMACCustomClaims decodes the accessToken signed with the given shared secret
+func DecodeAccessTokenHMACCustomClaims(accessToken string, secret []byte, customClaims jwt.Claims, options ...ValidationOption) (*jwt.Token, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("the secret to verify the token is empty")
+	}
+
+	return parseWithClaims(accessToken, customClaims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return secret, nil
+	}, options)
 }
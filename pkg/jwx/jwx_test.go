@@ -0,0 +1,143 @@
+package jwx
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeBigInt(i *big.Int) *string {
+	s := base64.RawURLEncoding.EncodeToString(i.Bytes())
+	return &s
+}
+
+func encodeRSAPublicKey(key *rsa.PublicKey) (*string, *string) {
+	return encodeBigInt(big.NewInt(int64(key.E))), encodeBigInt(key.N)
+}
+
+func encodeECDSAPublicKey(key *ecdsa.PublicKey) (*string, *string, *string) {
+	crv := key.Curve.Params().Name
+	return encodeBigInt(key.X), encodeBigInt(key.Y), &crv
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}) string {
+	token := jwt.NewWithClaims(method, jwt.MapClaims{"sub": "gocloak"})
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+	return signed
+}
+
+func TestDecodeAccessTokenHeader(t *testing.T) {
+	t.Parallel()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	header, err := DecodeAccessTokenHeader("Bearer " + signToken(t, jwt.SigningMethodRS256, key))
+	assert.NoError(t, err)
+	assert.Equal(t, "RS256", header.Alg)
+	assert.Equal(t, "test-key", header.Kid)
+}
+
+func TestDecodeAccessTokenRSACustomClaims(t *testing.T) {
+	t.Parallel()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	e, n := encodeRSAPublicKey(&key.PublicKey)
+
+	for _, method := range []jwt.SigningMethod{
+		jwt.SigningMethodRS256,
+		jwt.SigningMethodRS512,
+		jwt.SigningMethodPS256,
+		jwt.SigningMethodPS384,
+	} {
+		claims := jwt.MapClaims{}
+		token, err := DecodeAccessTokenRSACustomClaims(signToken(t, method, key), e, n, claims)
+		assert.NoError(t, err, "failed to decode a %s token", method.Alg())
+		assert.True(t, token.Valid)
+		assert.Equal(t, "gocloak", claims["sub"])
+	}
+}
+
+func TestDecodeAccessTokenECDSACustomClaims(t *testing.T) {
+	t.Parallel()
+	for method, curve := range map[jwt.SigningMethod]elliptic.Curve{
+		jwt.SigningMethodES256: elliptic.P256(),
+		jwt.SigningMethodES384: elliptic.P384(),
+		jwt.SigningMethodES512: elliptic.P521(),
+	} {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		assert.NoError(t, err)
+		x, y, crv := encodeECDSAPublicKey(&key.PublicKey)
+
+		claims := jwt.MapClaims{}
+		token, err := DecodeAccessTokenECDSACustomClaims(signToken(t, method, key), x, y, crv, claims)
+		assert.NoError(t, err, "failed to decode a %s token", method.Alg())
+		assert.True(t, token.Valid)
+		assert.Equal(t, "gocloak", claims["sub"])
+	}
+}
+
+func TestDecodeAccessTokenECDSACustomClaims_CurveMismatch(t *testing.T) {
+	t.Parallel()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	x, y, crv := encodeECDSAPublicKey(&key.PublicKey)
+
+	// an ES384 token signed with a P-256 key
+	method := &jwt.SigningMethodECDSA{Name: "ES384", Hash: crypto.SHA384, KeySize: 32, CurveBits: 256}
+	_, err = DecodeAccessTokenECDSACustomClaims(signToken(t, method, key), x, y, crv, jwt.MapClaims{})
+	assert.Error(t, err)
+}
+
+func TestDecodeAccessTokenHMACCustomClaims(t *testing.T) {
+	t.Parallel()
+	secret := []byte("realm-secret")
+	claims := jwt.MapClaims{}
+	token, err := DecodeAccessTokenHMACCustomClaims(signToken(t, jwt.SigningMethodHS256, secret), secret, claims)
+	assert.NoError(t, err)
+	assert.True(t, token.Valid)
+
+	_, err = DecodeAccessTokenHMACCustomClaims(signToken(t, jwt.SigningMethodHS256, secret), []byte("wrong"), jwt.MapClaims{})
+	assert.Error(t, err)
+	_, err = DecodeAccessTokenHMACCustomClaims(signToken(t, jwt.SigningMethodHS256, secret), nil, jwt.MapClaims{})
+	assert.Error(t, err)
+}
+
+func TestDecodeAccessToken_AlgorithmConfusion(t *testing.T) {
+	t.Parallel()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	e, n := encodeRSAPublicKey(&rsaKey.PublicKey)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	x, y, crv := encodeECDSAPublicKey(&ecKey.PublicKey)
+
+	// a token signed with HMAC using the public key as a secret must not be accepted
+	publicKey, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	assert.NoError(t, err)
+	_, err = DecodeAccessTokenRSACustomClaims(signToken(t, jwt.SigningMethodHS256, publicKey), e, n, jwt.MapClaims{})
+	assert.Error(t, err)
+
+	_, err = DecodeAccessTokenRSACustomClaims(signToken(t, jwt.SigningMethodES256, ecKey), e, n, jwt.MapClaims{})
+	assert.Error(t, err)
+	_, err = DecodeAccessTokenECDSACustomClaims(signToken(t, jwt.SigningMethodRS256, rsaKey), x, y, crv, jwt.MapClaims{})
+	assert.Error(t, err)
+	_, err = DecodeAccessTokenHMACCustomClaims(signToken(t, jwt.SigningMethodRS256, rsaKey), publicKey, jwt.MapClaims{})
+	assert.Error(t, err)
+
+	// "none" is never accepted
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	assert.NoError(t, err)
+	_, err = DecodeAccessTokenRSACustomClaims(unsigned, e, n, jwt.MapClaims{})
+	assert.Error(t, err)
+}
@@ -1,6 +1,10 @@
 package jwx
 
-import jwt "github.com/dgrijalva/jwt-go"
+import (
+	"encoding/json"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
 
 // DecodedAccessTokenHeader is the decoded header from the access token
 type DecodedAccessTokenHeader struct {
@@ -12,6 +16,7 @@ type DecodedAccessTokenHeader struct {
 // Claims served by keycloak inside the accessToken
 type Claims struct {
 	jwt.StandardClaims
+	Audience          Audience       `json:"aud,omitempty"`
 	Typ               string         `json:"typ,omitempty"`
 	Azp               string         `json:"azp,omitempty"`
 	AuthTime          int            `json:"auth_time,omitempty"`
@@ -31,6 +36,60 @@ type Claims struct {
 	ClientID          string         `json:"clientId,omitempty"`
 	ClientHost        string         `json:"clientHost,omitempty"`
 	ClientIP          string         `json:"clientAddress,omitempty"`
+	Authorization     *Authorization `json:"authorization,omitempty"`
+}
+
+// Audience holds the aud claim, which keycloak serves as a string or an array of strings
+type Audience []string
+
+// UnmarshalJSON unmarshals the audience from a JSON array or a JSON string
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	if len(data) > 1 && data[0] == '[' {
+		var obj []string
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		*a = Audience(obj)
+		return nil
+	}
+
+	var obj string
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*a = Audience([]string{obj})
+	return nil
+}
+
+// MarshalJSON converts the audience to a JSON string if there is only one item, otherwise to a JSON array
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// Contains returns true if the given audience is one of the audiences
+func (a Audience) Contains(audience string) bool {
+	for _, item := range a {
+		if item == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorization holds the permissions granted by a requesting party token
+type Authorization struct {
+	Permissions []Permission `json:"permissions,omitempty"`
+}
+
+// Permission is a resource and its scopes granted by a requesting party token
+type Permission struct {
+	ResourceID   string              `json:"rsid,omitempty"`
+	ResourceName string              `json:"rsname,omitempty"`
+	Scopes       []string            `json:"scopes,omitempty"`
+	Claims       map[string][]string `json:"claims,omitempty"`
 }
 
 // Address TODO what fields does any address have?
@@ -42,18 +101,20 @@ type RealmAccess struct {
 	Roles []string `json:"roles,omitempty"`
 }
 
-// ResourceAccess holds TODO: What does it hold?
-type ResourceAccess struct {
-	RealmManagement RealmManagement `json:"realm-management,omitempty"`
-	Account         Account         `json:"account,omitempty"`
-}
+// ResourceAccess holds the roles of the user per client ID
+type ResourceAccess map[string]ClientAccess
 
-// RealmManagement holds TODO: What does it hold?
-type RealmManagement struct {
+// ClientAccess holds the roles of the user for a client
+type ClientAccess struct {
 	Roles []string `json:"roles,omitempty"`
 }
 
-// Account holds TODO: What does it hold?
-type Account struct {
-	Roles []string `json:"roles,omitempty"`
-}
+// RealmManagement holds the roles of the user for the realm-management client
+//
+// Deprecated: use ResourceAccess["realm-management"]
+type RealmManagement = ClientAccess
+
+// Account holds the roles of the user for the account client
+//
+// Deprecated: use ResourceAccess["account"]
+type Account = ClientAccess
@@ -0,0 +1,25 @@
+package jwx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaims_Audience(t *testing.T) {
+	t.Parallel()
+	claims := Claims{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"aud":["account","gocloak"],"sub":"user"}`), &claims))
+	assert.Equal(t, Audience{"account", "gocloak"}, claims.Audience)
+	assert.True(t, claims.Audience.Contains("gocloak"))
+	assert.Equal(t, "user", claims.Subject)
+
+	claims = Claims{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"aud":"gocloak"}`), &claims))
+	assert.Equal(t, Audience{"gocloak"}, claims.Audience)
+
+	data, err := json.Marshal(claims)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"aud":"gocloak"`)
+}
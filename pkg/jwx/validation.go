@@ -0,0 +1,241 @@
+package jwx
+
+import (
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// ValidationOptions holds the checks applied to the claims of a decoded token
+type ValidationOptions struct {
+	// Issuer the iss claim must be equal to
+	Issuer string
+	// Audiences of which at least one must be contained in the aud claim
+	Audiences []string
+	// AuthorizedParty the azp claim must be equal to
+	AuthorizedParty string
+	// RequiredScopes which all must be contained in the scope claim
+	RequiredScopes []string
+	// ClockSkew allowed when the exp, nbf and iat claims are checked
+	ClockSkew time.Duration
+	// TokenTypes of which one must be equal to the typ claim, e.g. "Bearer" or "ID"
+	TokenTypes []string
+	// Nonce the nonce claim of an ID token must be equal to
+	Nonce string
+
+	now func() time.Time
+}
+
+// ValidationOption sets a check applied to the claims of a decoded token
+type ValidationOption func(*ValidationOptions)
+
+// WithIssuer checks that the token is issued by the given issuer
+func WithIssuer(issuer string) ValidationOption {
+	return func(o *ValidationOptions) {
+		o.Issuer = issuer
+	}
+}
+
+// WithAudiences checks that the token is issued for at least one of the given audiences
+func WithAudiences(audiences ...string) ValidationOption {
+	return func(o *ValidationOptions) {
+		o.Audiences = append(o.Audiences, audiences...)
+	}
+}
+
+// WithAuthorizedParty checks that the token is issued to the given client
+func WithAuthorizedParty(clientID string) ValidationOption {
+	return func(o *ValidationOptions) {
+		o.AuthorizedParty = clientID
+	}
+}
+
+// WithRequiredScopes checks that the token contains all the given scopes
+func WithRequiredScopes(scopes ...string) ValidationOption {
+	return func(o *ValidationOptions) {
+		o.RequiredScopes = append(o.RequiredScopes, scopes...)
+	}
+}
+
+// WithClockSkew sets the allowed clock skew between keycloak and the current host
+func WithClockSkew(skew time.Duration) ValidationOption {
+	return func(o *ValidationOptions) {
+		o.ClockSkew = skew
+	}
+}
+
+// WithTokenTypes checks that the typ claim is one of the given types
+func WithTokenTypes(types ...string) ValidationOption {
+	return func(o *ValidationOptions) {
+		o.TokenTypes = append(o.TokenTypes, types...)
+	}
+}
+
+// WithNonce checks that the nonce claim of an ID token is equal to the nonce sent in the authorization request
+func WithNonce(nonce string) ValidationOption {
+	return func(o *ValidationOptions) {
+		o.Nonce = nonce
+	}
+}
+
+// WithValidationOptions applies the checks set in the given options.
+// Fields which are not set keep the checks applied before, e.g. the issuer check of the client
+func WithValidationOptions(options ValidationOptions) ValidationOption {
+	return func(o *ValidationOptions) {
+		if len(options.Issuer) > 0 {
+			o.Issuer = options.Issuer
+		}
+		o.Audiences = append(o.Audiences, options.Audiences...)
+		if len(options.AuthorizedParty) > 0 {
+			o.AuthorizedParty = options.AuthorizedParty
+		}
+		o.RequiredScopes = append(o.RequiredScopes, options.RequiredScopes...)
+		if options.ClockSkew != 0 {
+			o.ClockSkew = options.ClockSkew
+		}
+		o.TokenTypes = append(o.TokenTypes, options.TokenTypes...)
+		if len(options.Nonce) > 0 {
+			o.Nonce = options.Nonce
+		}
+		if options.now != nil {
+			o.now = options.now
+		}
+	}
+}
+
+// Validate checks the given claims and returns a typed error for the first failed check
+func (o *ValidationOptions) Validate(claims jwt.MapClaims) error {
+	now := time.Now()
+	if o.now != nil {
+		now = o.now()
+	}
+
+	if exp, ok := numericClaim(claims, "exp"); ok && now.After(exp.Add(o.ClockSkew)) {
+		return &TokenExpiredError{ExpiredAt: exp}
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Before(nbf.Add(-o.ClockSkew)) {
+		return &TokenNotValidYetError{NotBefore: nbf}
+	}
+	if iat, ok := numericClaim(claims, "iat"); ok && now.Before(iat.Add(-o.ClockSkew)) {
+		return &TokenNotValidYetError{NotBefore: iat}
+	}
+
+	if len(o.Issuer) > 0 {
+		if iss := stringClaim(claims, "iss"); iss != o.Issuer {
+			return &InvalidIssuerError{Expected: o.Issuer, Actual: iss}
+		}
+	}
+
+	if len(o.Audiences) > 0 {
+		aud := stringsClaim(claims, "aud")
+		if !containsAny(aud, o.Audiences) {
+			return &InvalidAudienceError{Expected: o.Audiences, Actual: aud}
+		}
+	}
+
+	if len(o.AuthorizedParty) > 0 {
+		if azp := stringClaim(claims, "azp"); azp != o.AuthorizedParty {
+			return &InvalidAuthorizedPartyError{Expected: o.AuthorizedParty, Actual: azp}
+		}
+	}
+
+	if len(o.RequiredScopes) > 0 {
+		scopes := strings.Fields(stringClaim(claims, "scope"))
+		var missing []string
+		for _, scope := range o.RequiredScopes {
+			if !containsAny(scopes, []string{scope}) {
+				missing = append(missing, scope)
+			}
+		}
+		if len(missing) > 0 {
+			return &MissingScopesError{Missing: missing}
+		}
+	}
+
+	if len(o.TokenTypes) > 0 {
+		typ := stringClaim(claims, "typ")
+		if !containsAny([]string{typ}, o.TokenTypes) {
+			return &InvalidTokenTypeError{Expected: o.TokenTypes, Actual: typ}
+		}
+	}
+
+	if len(o.Nonce) > 0 {
+		if nonce := stringClaim(claims, "nonce"); nonce != o.Nonce {
+			return &InvalidNonceError{}
+		}
+	}
+
+	return nil
+}
+
+// parseWithClaims parses the token and, if any options are given, validates its claims with them
+// instead of the default validation of the claims
+func parseWithClaims(accessToken string, claims jwt.Claims, keyFunc jwt.Keyfunc, options []ValidationOption) (*jwt.Token, error) {
+	if len(options) == 0 {
+		return jwt.ParseWithClaims(accessToken, claims, keyFunc)
+	}
+
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(accessToken, claims, keyFunc)
+	if err != nil {
+		return token, err
+	}
+
+	mapClaims := jwt.MapClaims{}
+	if _, _, err := parser.ParseUnverified(accessToken, mapClaims); err != nil {
+		return token, err
+	}
+
+	opts := ValidationOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+	if err := opts.Validate(mapClaims); err != nil {
+		token.Valid = false
+		return token, err
+	}
+	return token, nil
+}
+
+func numericClaim(claims jwt.MapClaims, name string) (time.Time, bool) {
+	switch value := claims[name].(type) {
+	case float64:
+		return time.Unix(int64(value), 0), true
+	case int64:
+		return time.Unix(value, 0), true
+	}
+	return time.Time{}, false
+}
+
+func stringClaim(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+func stringsClaim(claims jwt.MapClaims, name string) []string {
+	switch value := claims[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		result := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+func containsAny(values []string, expected []string) bool {
+	for _, value := range values {
+		for _, e := range expected {
+			if value == e {
+				return true
+			}
+		}
+	}
+	return false
+}
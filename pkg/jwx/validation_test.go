@@ -0,0 +1,151 @@
+package jwx
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func testClaims() jwt.MapClaims {
+	now := time.Now().Unix()
+	return jwt.MapClaims{
+		"exp":   float64(now + 60),
+		"iat":   float64(now),
+		"nbf":   float64(now),
+		"iss":   "http://localhost:8080/auth/realms/gocloak",
+		"aud":   []interface{}{"account", "gocloak"},
+		"azp":   "gocloak",
+		"typ":   "Bearer",
+		"scope": "openid email profile",
+	}
+}
+
+func TestValidationOptions_Validate(t *testing.T) {
+	t.Parallel()
+	options := ValidationOptions{
+		Issuer:          "http://localhost:8080/auth/realms/gocloak",
+		Audiences:       []string{"gocloak"},
+		AuthorizedParty: "gocloak",
+		RequiredScopes:  []string{"openid", "email"},
+		TokenTypes:      []string{"Bearer"},
+	}
+	assert.NoError(t, options.Validate(testClaims()))
+
+	claims := testClaims()
+	claims["aud"] = "gocloak"
+	assert.NoError(t, options.Validate(claims), "a single audience must be accepted")
+}
+
+func TestValidationOptions_Validate_Errors(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+
+	testCases := []struct {
+		name    string
+		options ValidationOptions
+		claims  func(jwt.MapClaims)
+		target  interface{}
+	}{
+		{
+			name:   "expired",
+			claims: func(c jwt.MapClaims) { c["exp"] = float64(now.Add(-time.Minute).Unix()) },
+			target: new(*TokenExpiredError),
+		},
+		{
+			name:   "not valid yet",
+			claims: func(c jwt.MapClaims) { c["nbf"] = float64(now.Add(time.Minute).Unix()) },
+			target: new(*TokenNotValidYetError),
+		},
+		{
+			name:    "issuer",
+			options: ValidationOptions{Issuer: "http://localhost:8080/auth/realms/master"},
+			target:  new(*InvalidIssuerError),
+		},
+		{
+			name:    "audience",
+			options: ValidationOptions{Audiences: []string{"other"}},
+			target:  new(*InvalidAudienceError),
+		},
+		{
+			name:    "authorized party",
+			options: ValidationOptions{AuthorizedParty: "other"},
+			target:  new(*InvalidAuthorizedPartyError),
+		},
+		{
+			name:    "scopes",
+			options: ValidationOptions{RequiredScopes: []string{"openid", "offline_access"}},
+			target:  new(*MissingScopesError),
+		},
+		{
+			name:    "token type",
+			options: ValidationOptions{TokenTypes: []string{"ID"}},
+			target:  new(*InvalidTokenTypeError),
+		},
+		{
+			name:    "nonce",
+			options: ValidationOptions{Nonce: "expected"},
+			claims:  func(c jwt.MapClaims) { c["nonce"] = "other" },
+			target:  new(*InvalidNonceError),
+		},
+	}
+
+	for _, testCase := range testCases {
+		claims := testClaims()
+		if testCase.claims != nil {
+			testCase.claims(claims)
+		}
+		err := testCase.options.Validate(claims)
+		assert.Error(t, err, testCase.name)
+		assert.True(t, errors.As(err, testCase.target), "%s: unexpected error type %T", testCase.name, err)
+	}
+}
+
+func TestValidationOptions_ClockSkew(t *testing.T) {
+	t.Parallel()
+	claims := testClaims()
+	claims["exp"] = float64(time.Now().Add(-10 * time.Second).Unix())
+	options := ValidationOptions{}
+	assert.Error(t, options.Validate(claims))
+	options.ClockSkew = time.Minute
+	assert.NoError(t, options.Validate(claims))
+}
+
+func TestWithValidationOptions_KeepsEarlierChecks(t *testing.T) {
+	t.Parallel()
+	options := ValidationOptions{}
+	for _, option := range []ValidationOption{
+		WithIssuer("http://localhost:8080/auth/realms/other"),
+		WithAudiences("gocloak"),
+		WithValidationOptions(ValidationOptions{AuthorizedParty: "gocloak", Audiences: []string{"account"}}),
+	} {
+		option(&options)
+	}
+	assert.Equal(t, "http://localhost:8080/auth/realms/other", options.Issuer)
+	assert.Equal(t, []string{"gocloak", "account"}, options.Audiences)
+	assert.Equal(t, "gocloak", options.AuthorizedParty)
+	var issuerError *InvalidIssuerError
+	assert.True(t, errors.As(options.Validate(testClaims()), &issuerError))
+}
+
+func TestDecodeAccessTokenHMACCustomClaims_Validation(t *testing.T) {
+	t.Parallel()
+	secret := []byte("realm-secret")
+	mapClaims := testClaims()
+	mapClaims["aud"] = "gocloak"
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, mapClaims).SignedString(secret)
+	assert.NoError(t, err)
+
+	claims := &Claims{}
+	token, err := DecodeAccessTokenHMACCustomClaims(signed, secret, claims, WithAuthorizedParty("gocloak"), WithRequiredScopes("openid"))
+	assert.NoError(t, err)
+	assert.True(t, token.Valid)
+	assert.Equal(t, "gocloak", claims.Azp)
+
+	token, err = DecodeAccessTokenHMACCustomClaims(signed, secret, &Claims{}, WithAudiences("other"))
+	assert.False(t, token.Valid)
+	var audienceError *InvalidAudienceError
+	assert.True(t, errors.As(err, &audienceError), "unexpected error: %v", err)
+}
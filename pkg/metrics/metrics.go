@@ -0,0 +1,86 @@
+// Package metrics counts the requests of the gocloak client with the Prometheus client library
+package metrics
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/kkovarik/gocloak"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultBuckets are the upper bounds of the buckets of the request duration histogram in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics counts the requests by operation, realm and status code.
+// It is an instrumentation of the client and a Prometheus collector, which is registered with a registry:
+//
+//	gocloak_requests_total{operation,realm,code}, code is "error" for requests without a response
+//	gocloak_request_retries_total{operation,realm}
+//	gocloak_request_duration_seconds{operation,realm}, a histogram
+type Metrics struct {
+	requests  *prometheus.CounterVec
+	retries   *prometheus.CounterVec
+	durations *prometheus.HistogramVec
+}
+
+// New returns metrics with the upper bounds of the buckets of the duration histogram in seconds,
+// or DefaultBuckets if no buckets are given
+func New(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gocloak_requests_total",
+			Help: "The number of requests to keycloak.",
+		}, []string{"operation", "realm", "code"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gocloak_request_retries_total",
+			Help: "The number of retries of requests to keycloak.",
+		}, []string{"operation", "realm"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gocloak_request_duration_seconds",
+			Help:    "The duration of requests to keycloak including their retries.",
+			Buckets: buckets,
+		}, []string{"operation", "realm"}),
+	}
+}
+
+var (
+	_ gocloak.Instrumentation = (*Metrics)(nil)
+	_ prometheus.Collector    = (*Metrics)(nil)
+)
+
+// StartRequest returns the context, the metrics are recorded when the request ends
+func (m *Metrics) StartRequest(ctx context.Context, operation string) context.Context {
+	return ctx
+}
+
+// EndRequest counts the request, its retries and its duration
+func (m *Metrics) EndRequest(ctx context.Context, info gocloak.RequestInfo) {
+	code := "error"
+	if info.StatusCode != 0 {
+		code = strconv.Itoa(info.StatusCode)
+	}
+	m.requests.WithLabelValues(info.Operation, info.Realm, code).Inc()
+	m.retries.WithLabelValues(info.Operation, info.Realm).Add(float64(info.Retries))
+	m.durations.WithLabelValues(info.Operation, info.Realm).Observe(info.Latency.Seconds())
+}
+
+// Describe sends the descriptors of the metrics
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.retries.Describe(ch)
+	m.durations.Describe(ch)
+}
+
+// Collect sends the current values of the metrics
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.retries.Collect(ch)
+	m.durations.Collect(ch)
+}
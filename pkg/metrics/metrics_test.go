@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kkovarik/gocloak"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	metrics := New(0.1, 0.01)
+	ctx := metrics.StartRequest(context.Background(), "GetUsers")
+	metrics.EndRequest(ctx, gocloak.RequestInfo{Operation: "GetUsers", Realm: "realm", StatusCode: 200, Latency: 5 * time.Millisecond})
+	metrics.EndRequest(ctx, gocloak.RequestInfo{Operation: "GetUsers", Realm: "realm", StatusCode: 200, Latency: 50 * time.Millisecond, Retries: 2})
+	metrics.EndRequest(ctx, gocloak.RequestInfo{Operation: "GetUsers", Realm: "realm", Latency: time.Second, Err: errors.New("refused")})
+	metrics.EndRequest(ctx, gocloak.RequestInfo{Operation: "GetToken", Realm: `my "realm"`, StatusCode: 401, Latency: 10 * time.Millisecond})
+
+	assert.NoError(t, testutil.CollectAndCompare(metrics, strings.NewReader(strings.Join([]string{
+		`# HELP gocloak_requests_total The number of requests to keycloak.`,
+		`# TYPE gocloak_requests_total counter`,
+		`gocloak_requests_total{code="401",operation="GetToken",realm="my \"realm\""} 1`,
+		`gocloak_requests_total{code="200",operation="GetUsers",realm="realm"} 2`,
+		`gocloak_requests_total{code="error",operation="GetUsers",realm="realm"} 1`,
+		`# HELP gocloak_request_retries_total The number of retries of requests to keycloak.`,
+		`# TYPE gocloak_request_retries_total counter`,
+		`gocloak_request_retries_total{operation="GetToken",realm="my \"realm\""} 0`,
+		`gocloak_request_retries_total{operation="GetUsers",realm="realm"} 2`,
+		`# HELP gocloak_request_duration_seconds The duration of requests to keycloak including their retries.`,
+		`# TYPE gocloak_request_duration_seconds histogram`,
+		`gocloak_request_duration_seconds_bucket{operation="GetToken",realm="my \"realm\"",le="0.01"} 1`,
+		`gocloak_request_duration_seconds_bucket{operation="GetToken",realm="my \"realm\"",le="0.1"} 1`,
+		`gocloak_request_duration_seconds_bucket{operation="GetToken",realm="my \"realm\"",le="+Inf"} 1`,
+		`gocloak_request_duration_seconds_sum{operation="GetToken",realm="my \"realm\""} 0.01`,
+		`gocloak_request_duration_seconds_count{operation="GetToken",realm="my \"realm\""} 1`,
+		`gocloak_request_duration_seconds_bucket{operation="GetUsers",realm="realm",le="0.01"} 1`,
+		`gocloak_request_duration_seconds_bucket{operation="GetUsers",realm="realm",le="0.1"} 2`,
+		`gocloak_request_duration_seconds_bucket{operation="GetUsers",realm="realm",le="+Inf"} 3`,
+		`gocloak_request_duration_seconds_sum{operation="GetUsers",realm="realm"} 1.055`,
+		`gocloak_request_duration_seconds_count{operation="GetUsers",realm="realm"} 3`,
+		``,
+	}, "\n"))))
+}
+
+func TestMetrics_Client(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	metrics := New()
+
+	client := gocloak.NewClient(server.URL, gocloak.SetInstrumentation(metrics))
+	_, err := client.GetUsers(context.Background(), "token", "realm", gocloak.GetUsersParams{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("GetUsers", "realm", "200")))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics, "gocloak_request_duration_seconds"))
+}
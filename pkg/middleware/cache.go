@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/kkovarik/gocloak/pkg/jwx"
+)
+
+type claimsEntry struct {
+	claims    *jwx.Claims
+	expiresAt time.Time
+}
+
+// claimsCache keeps the claims of verified tokens until the tokens expire.
+// The tokens are keyed by their hash, so the cache holds no usable credentials
+type claimsCache struct {
+	size    int
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]claimsEntry
+}
+
+func newClaimsCache(size int) *claimsCache {
+	return &claimsCache{
+		size:    size,
+		entries: make(map[[sha256.Size]byte]claimsEntry),
+	}
+}
+
+// get returns a copy of the cached claims, so handlers cannot change the claims seen by other requests
+func (c *claimsCache) get(token string, now time.Time) (*jwx.Claims, bool) {
+	key := sha256.Sum256([]byte(token))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !now.Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return copyClaims(entry.claims), true
+}
+
+func (c *claimsCache) set(token string, claims *jwx.Claims, expiresAt time.Time, now time.Time) {
+	if !now.Before(expiresAt) {
+		return
+	}
+	key := sha256.Sum256([]byte(token))
+	stored := copyClaims(claims)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.size {
+		c.evict(now)
+	}
+	c.entries[key] = claimsEntry{claims: stored, expiresAt: expiresAt}
+}
+
+// evict removes the expired entries, or an arbitrary one if none has expired yet
+func (c *claimsCache) evict(now time.Time) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	for key := range c.entries {
+		if len(c.entries) < c.size {
+			return
+		}
+		delete(c.entries, key)
+	}
+}
+
+// copyClaims returns a deep copy of the claims
+func copyClaims(claims *jwx.Claims) *jwx.Claims {
+	result := *claims
+	result.Audience = copyStrings(claims.Audience)
+	result.AllowedOrigins = copyStrings(claims.AllowedOrigins)
+	result.RealmAccess.Roles = copyStrings(claims.RealmAccess.Roles)
+	if claims.ResourceAccess != nil {
+		result.ResourceAccess = make(jwx.ResourceAccess, len(claims.ResourceAccess))
+		for clientID, access := range claims.ResourceAccess {
+			result.ResourceAccess[clientID] = jwx.ClientAccess{Roles: copyStrings(access.Roles)}
+		}
+	}
+	if claims.Authorization != nil {
+		authorization := jwx.Authorization{}
+		if claims.Authorization.Permissions != nil {
+			authorization.Permissions = make([]jwx.Permission, len(claims.Authorization.Permissions))
+			for i, permission := range claims.Authorization.Permissions {
+				permission.Scopes = copyStrings(permission.Scopes)
+				if permission.Claims != nil {
+					permissionClaims := make(map[string][]string, len(permission.Claims))
+					for name, values := range permission.Claims {
+						permissionClaims[name] = copyStrings(values)
+					}
+					permission.Claims = permissionClaims
+				}
+				authorization.Permissions[i] = permission
+			}
+		}
+		result.Authorization = &authorization
+	}
+	return &result
+}
+
+func copyStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(values)), values...)
+}
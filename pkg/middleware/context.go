@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/kkovarik/gocloak/pkg/jwx"
+)
+
+type contextKey int
+
+const (
+	tokenKey contextKey = iota
+	claimsKey
+)
+
+// NewContext returns a copy of the context carrying the access token and its claims
+func NewContext(ctx context.Context, token string, claims *jwx.Claims) context.Context {
+	ctx = context.WithValue(ctx, tokenKey, token)
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// ClaimsFromContext returns the claims of the authenticated token
+func ClaimsFromContext(ctx context.Context) (*jwx.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*jwx.Claims)
+	return claims, ok && claims != nil
+}
+
+// TokenFromContext returns the authenticated access token
+func TokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(tokenKey).(string)
+	return token, ok && token != ""
+}
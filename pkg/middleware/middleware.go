@@ -0,0 +1,220 @@
+// Package middleware authenticates net/http requests with access tokens issued by keycloak
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kkovarik/gocloak"
+	"github.com/kkovarik/gocloak/pkg/jwx"
+)
+
+// ErrMissingToken is returned when the request carries no access token
+var ErrMissingToken = errors.New("no access token found in the request")
+
+// ErrForbidden is returned when the claims of the token are denied by the policy
+var ErrForbidden = errors.New("the token does not grant access")
+
+// TokenExtractor returns the access token of the request or an empty string if there is none
+type TokenExtractor func(r *http.Request) string
+
+// FromAuthorizationHeader extracts the token from the "Authorization: Bearer <token>" header
+func FromAuthorizationHeader() TokenExtractor {
+	return func(r *http.Request) string {
+		header := r.Header.Get("Authorization")
+		if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+			return strings.TrimSpace(header[7:])
+		}
+		return ""
+	}
+}
+
+// FromHeader extracts the token from the given header
+func FromHeader(name string) TokenExtractor {
+	return func(r *http.Request) string {
+		return strings.TrimSpace(r.Header.Get(name))
+	}
+}
+
+// FromCookie extracts the token from the given cookie
+func FromCookie(name string) TokenExtractor {
+	return func(r *http.Request) string {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	}
+}
+
+// FromQuery extracts the token from the given query parameter
+func FromQuery(name string) TokenExtractor {
+	return func(r *http.Request) string {
+		return r.URL.Query().Get(name)
+	}
+}
+
+// ErrorHandler writes the response for a request which could not be authenticated
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// Option configures the Middleware
+type Option func(*Middleware)
+
+// WithExtractors sets the extractors which are tried in order until one returns a token.
+// By default the token is read from the Authorization header
+func WithExtractors(extractors ...TokenExtractor) Option {
+	return func(m *Middleware) {
+		m.extractors = extractors
+	}
+}
+
+// WithValidation sets the checks applied to the claims of the token
+func WithValidation(options ...jwx.ValidationOption) Option {
+	return func(m *Middleware) {
+		m.validation = append(m.validation, options...)
+	}
+}
+
+// WithCacheSize sets how many verified tokens are cached until they expire, 0 disables the cache
+func WithCacheSize(size int) Option {
+	return func(m *Middleware) {
+		m.cacheSize = size
+	}
+}
+
+// WithPolicy sets the policy every authenticated request must satisfy
+func WithPolicy(policy jwx.Policy) Option {
+	return func(m *Middleware) {
+		m.policy = policy
+	}
+}
+
+// WithErrorHandler sets the handler called for requests which could not be authenticated
+func WithErrorHandler(handler ErrorHandler) Option {
+	return func(m *Middleware) {
+		m.errorHandler = handler
+	}
+}
+
+// Middleware verifies the access token of every request and puts its claims into the request context
+type Middleware struct {
+	client       gocloak.GoCloak
+	realm        string
+	extractors   []TokenExtractor
+	validation   []jwx.ValidationOption
+	cacheSize    int
+	cache        *claimsCache
+	policy       jwx.Policy
+	errorHandler ErrorHandler
+	now          func() time.Time
+}
+
+// New creates a Middleware verifying tokens of the given realm
+func New(client gocloak.GoCloak, realm string, options ...Option) *Middleware {
+	m := &Middleware{
+		client:       client,
+		realm:        realm,
+		extractors:   []TokenExtractor{FromAuthorizationHeader()},
+		cacheSize:    1000,
+		errorHandler: DefaultErrorHandler,
+		now:          time.Now,
+	}
+	for _, option := range options {
+		option(m)
+	}
+	if m.cacheSize > 0 {
+		m.cache = newClaimsCache(m.cacheSize)
+	}
+	return m
+}
+
+// Handler wraps the given handler, requests without a valid token are passed to the error handler
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, claims, err := m.Authenticate(r)
+		if err != nil {
+			m.errorHandler(w, r, err)
+			return
+		}
+		if m.policy != nil && !m.policy.Allowed(claims) {
+			m.errorHandler(w, r, ErrForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), token, claims)))
+	})
+}
+
+// HandlerFunc wraps the given handler function
+func (m *Middleware) HandlerFunc(next http.HandlerFunc) http.HandlerFunc {
+	return m.Handler(next).ServeHTTP
+}
+
+// Authenticate extracts and verifies the token of the request and returns it with its claims
+func (m *Middleware) Authenticate(r *http.Request) (string, *jwx.Claims, error) {
+	token := m.extractToken(r)
+	if token == "" {
+		return "", nil, ErrMissingToken
+	}
+
+	if m.cache != nil {
+		if claims, ok := m.cache.get(token, m.now()); ok {
+			return token, claims, nil
+		}
+	}
+
+	claims := &jwx.Claims{}
+	if _, err := m.client.DecodeAccessTokenCustomClaims(r.Context(), token, m.realm, claims, m.validation...); err != nil {
+		return "", nil, err
+	}
+
+	if m.cache != nil && claims.ExpiresAt > 0 {
+		m.cache.set(token, claims, time.Unix(claims.ExpiresAt, 0), m.now())
+	}
+	return token, claims, nil
+}
+
+func (m *Middleware) extractToken(r *http.Request) string {
+	for _, extractor := range m.extractors {
+		if token := extractor(r); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// Require wraps handlers behind the Middleware, so they are only called if the claims satisfy the policy.
+// Denied requests are answered by the DefaultErrorHandler
+func Require(policy jwx.Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				DefaultErrorHandler(w, r, ErrMissingToken)
+				return
+			}
+			if !policy.Allowed(claims) {
+				DefaultErrorHandler(w, r, ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DefaultErrorHandler responds with 401 Unauthorized, or 403 Forbidden if the policy denied access,
+// and a WWW-Authenticate header as described in RFC 6750
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case ErrMissingToken:
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+	case ErrForbidden:
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	default:
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	}
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
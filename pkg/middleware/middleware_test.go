@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/kkovarik/gocloak"
+	"github.com/kkovarik/gocloak/pkg/jwx"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testRealm  = "gocloak"
+	testSecret = "realm-secret"
+)
+
+type countingClient struct {
+	gocloak.GoCloak
+	decodes int32
+}
+
+func (c *countingClient) DecodeAccessTokenCustomClaims(ctx context.Context, accessToken string, realm string, claims jwt.Claims, options ...jwx.ValidationOption) (*jwt.Token, error) {
+	atomic.AddInt32(&c.decodes, 1)
+	return c.GoCloak.DecodeAccessTokenCustomClaims(ctx, accessToken, realm, claims, options...)
+}
+
+func newTestClient() *countingClient {
+	// HMAC signed tokens are verified with the realm secret, so no keycloak is needed
+	client := gocloak.NewClient("http://127.0.0.1:1")
+	client.SetRealmSecret(testRealm, []byte(testSecret))
+	return &countingClient{GoCloak: client}
+}
+
+func signToken(t *testing.T, claims jwt.MapClaims) string {
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	assert.NoError(t, err)
+	return signed
+}
+
+func validToken(t *testing.T) string {
+	return signToken(t, jwt.MapClaims{
+		"exp":                float64(time.Now().Add(time.Minute).Unix()),
+		"sub":                "user",
+		"aud":                []string{"account", "gocloak"},
+		"preferred_username": "john",
+		"realm_access":       map[string]interface{}{"roles": []string{"user"}},
+		"resource_access": map[string]interface{}{
+			"my-client": map[string]interface{}{"roles": []string{"reader"}},
+		},
+	})
+}
+
+func claimsHandler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := ClaimsFromContext(r.Context())
+		assert.True(t, ok)
+		_, ok = TokenFromContext(r.Context())
+		assert.True(t, ok)
+		_, _ = w.Write([]byte(claims.PreferredUsername))
+	})
+}
+
+func TestMiddleware_AuthorizationHeader(t *testing.T) {
+	t.Parallel()
+	handler := New(newTestClient(), testRealm).Handler(claimsHandler(t))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+validToken(t))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "john", rec.Body.String())
+}
+
+func TestMiddleware_Extractors(t *testing.T) {
+	t.Parallel()
+	handler := New(newTestClient(), testRealm, WithExtractors(
+		FromAuthorizationHeader(),
+		FromCookie("access_token"),
+		FromQuery("access_token"),
+	)).Handler(claimsHandler(t))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "access_token", Value: validToken(t)})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/?access_token="+url.QueryEscape(validToken(t)), nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestMiddleware_Unauthorized(t *testing.T) {
+	t.Parallel()
+	handler := New(newTestClient(), testRealm).Handler(claimsHandler(t))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+
+	expired := signToken(t, jwt.MapClaims{"exp": float64(time.Now().Add(-time.Minute).Unix())})
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+expired)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, rec.Header().Get("WWW-Authenticate"))
+}
+
+func TestMiddleware_Validation(t *testing.T) {
+	t.Parallel()
+	handler := New(newTestClient(), testRealm, WithValidation(jwx.WithAudiences("other"))).Handler(claimsHandler(t))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+validToken(t))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestMiddleware_Policy(t *testing.T) {
+	t.Parallel()
+	client := newTestClient()
+	token := validToken(t)
+	serve := func(handler http.Handler) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	m := New(client, testRealm, WithPolicy(jwx.RequireClientRole("my-client", "reader")))
+	assert.Equal(t, http.StatusOK, serve(m.Handler(claimsHandler(t))))
+
+	m = New(client, testRealm, WithPolicy(jwx.RequireRealmRole("admin")))
+	assert.Equal(t, http.StatusForbidden, serve(m.Handler(claimsHandler(t))))
+
+	m = New(client, testRealm)
+	writer := Require(jwx.Or(jwx.RequireRealmRole("admin"), jwx.RequireClientRole("my-client", "writer")))
+	assert.Equal(t, http.StatusForbidden, serve(m.Handler(writer(claimsHandler(t)))))
+	reader := Require(jwx.RequireAnyRole(jwx.RealmRole("user")))
+	assert.Equal(t, http.StatusOK, serve(m.Handler(reader(claimsHandler(t)))))
+}
+
+func TestMiddleware_CachesVerifiedTokens(t *testing.T) {
+	t.Parallel()
+	client := newTestClient()
+	m := New(client, testRealm)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	handler := m.Handler(claimsHandler(t))
+	token := validToken(t)
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&client.decodes))
+
+	// the cached result is dropped when the token expires and the token is verified again
+	now = now.Add(2 * time.Minute)
+	_, _, err := m.Authenticate(func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&client.decodes))
+}
+
+func TestClaimsCache_Eviction(t *testing.T) {
+	t.Parallel()
+	cache := newClaimsCache(2)
+	now := time.Now()
+	cache.set("a", &jwx.Claims{}, now.Add(time.Minute), now)
+	cache.set("b", &jwx.Claims{}, now.Add(time.Minute), now)
+	cache.set("c", &jwx.Claims{}, now.Add(time.Minute), now)
+	assert.Len(t, cache.entries, 2)
+	_, ok := cache.get("c", now)
+	assert.True(t, ok)
+}
+
+func TestClaimsCache_Copies(t *testing.T) {
+	t.Parallel()
+	cache := newClaimsCache(2)
+	now := time.Now()
+	cache.set("a", &jwx.Claims{
+		RealmAccess:    jwx.RealmAccess{Roles: []string{"user"}},
+		ResourceAccess: jwx.ResourceAccess{"client": {Roles: []string{"admin"}}},
+		Authorization:  &jwx.Authorization{Permissions: []jwx.Permission{{ResourceName: "resource", Scopes: []string{"read"}}}},
+	}, now.Add(time.Minute), now)
+
+	claims, ok := cache.get("a", now)
+	assert.True(t, ok)
+	claims.RealmAccess.Roles[0] = "changed"
+	claims.ResourceAccess["client"].Roles[0] = "changed"
+	claims.ResourceAccess["other"] = jwx.ClientAccess{}
+	claims.Authorization.Permissions[0].Scopes[0] = "changed"
+
+	claims, ok = cache.get("a", now)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"user"}, claims.RealmAccess.Roles)
+	assert.Equal(t, jwx.ResourceAccess{"client": {Roles: []string{"admin"}}}, claims.ResourceAccess)
+	assert.Equal(t, []string{"read"}, claims.Authorization.Permissions[0].Scopes)
+}
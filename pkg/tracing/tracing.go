@@ -0,0 +1,66 @@
+// Package tracing records the requests of the gocloak client as OpenTelemetry spans
+package tracing
+
+import (
+	"context"
+
+	"github.com/kkovarik/gocloak"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer
+const instrumentationName = "github.com/kkovarik/gocloak"
+
+// The attributes of the spans besides the HTTP semantic conventions
+const (
+	// RealmKey is the realm of the request
+	RealmKey = attribute.Key("keycloak.realm")
+	// RetriesKey is the number of retries after the first attempt
+	RetriesKey = attribute.Key("gocloak.retries")
+)
+
+// Instrumentation starts a client span for each request, named after the operation, e.g. "gocloak.GetUsers"
+type Instrumentation struct {
+	tracer trace.Tracer
+}
+
+// New returns an instrumentation which creates the spans with the tracer provider,
+// or with the global tracer provider if the provider is nil
+func New(provider trace.TracerProvider) *Instrumentation {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return &Instrumentation{tracer: provider.Tracer(instrumentationName)}
+}
+
+var _ gocloak.Instrumentation = (*Instrumentation)(nil)
+
+// StartRequest starts the span of the request as child of the span in the context
+func (i *Instrumentation) StartRequest(ctx context.Context, operation string) context.Context {
+	ctx, _ = i.tracer.Start(ctx, "gocloak."+operation, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx
+}
+
+// EndRequest adds the request to the span and ends it.
+// The status of the span is an error if the request failed
+func (i *Instrumentation) EndRequest(ctx context.Context, info gocloak.RequestInfo) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(
+		semconv.HTTPMethodKey.String(info.Method),
+		semconv.HTTPURLKey.String(info.URL),
+		RealmKey.String(info.Realm),
+		RetriesKey.Int(info.Retries),
+	)
+	if info.StatusCode != 0 {
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(info.StatusCode))
+	}
+	if info.Err != nil {
+		span.RecordError(info.Err)
+		span.SetStatus(codes.Error, info.Err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,121 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kkovarik/gocloak"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type testSpan struct {
+	trace.Span
+	name       string
+	kind       trace.SpanKind
+	attributes map[attribute.Key]attribute.Value
+	errors     []error
+	status     codes.Code
+	ended      bool
+}
+
+func (s *testSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, attr := range kv {
+		s.attributes[attr.Key] = attr.Value
+	}
+}
+
+func (s *testSpan) RecordError(err error, options ...trace.EventOption) {
+	s.errors = append(s.errors, err)
+}
+
+func (s *testSpan) SetStatus(code codes.Code, description string) {
+	s.status = code
+}
+
+func (s *testSpan) End(options ...trace.SpanEndOption) {
+	s.ended = true
+}
+
+type testTracerProvider struct {
+	noop.TracerProvider
+	tracer *testTracer
+}
+
+func (p *testTracerProvider) Tracer(name string, options ...trace.TracerOption) trace.Tracer {
+	return p.tracer
+}
+
+type testTracer struct {
+	noop.Tracer
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	config := trace.NewSpanStartConfig(options...)
+	span := &testSpan{
+		Span:       trace.SpanFromContext(ctx),
+		name:       name,
+		kind:       config.SpanKind(),
+		attributes: make(map[attribute.Key]attribute.Value),
+	}
+	t.spans = append(t.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func TestInstrumentation(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/auth/admin/realms/realm/users" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":"forbidden"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	tracer := &testTracer{}
+
+	client := gocloak.NewClient(server.URL, gocloak.SetInstrumentation(New(&testTracerProvider{tracer: tracer})))
+	_, err := client.GetUsers(context.Background(), "token", "realm", gocloak.GetUsersParams{})
+	assert.NoError(t, err)
+	_, err = client.GetGroups(context.Background(), "token", "other", gocloak.GetGroupsParams{})
+	assert.True(t, gocloak.IsForbidden(err))
+
+	if assert.Len(t, tracer.spans, 2) {
+		span := tracer.spans[0]
+		assert.Equal(t, "gocloak.GetUsers", span.name)
+		assert.Equal(t, trace.SpanKindClient, span.kind)
+		assert.True(t, span.ended)
+		assert.Equal(t, "GET", span.attributes["http.method"].AsString())
+		assert.Equal(t, server.URL+"/auth/admin/realms/realm/users", span.attributes["http.url"].AsString())
+		assert.EqualValues(t, http.StatusOK, span.attributes["http.status_code"].AsInt64())
+		assert.Equal(t, "realm", span.attributes[RealmKey].AsString())
+		assert.EqualValues(t, 0, span.attributes[RetriesKey].AsInt64())
+		assert.Equal(t, codes.Unset, span.status)
+
+		span = tracer.spans[1]
+		assert.Equal(t, "gocloak.GetGroups", span.name)
+		assert.True(t, span.ended)
+		assert.EqualValues(t, http.StatusForbidden, span.attributes["http.status_code"].AsInt64())
+		assert.Equal(t, []error{err}, span.errors)
+		assert.Equal(t, codes.Error, span.status)
+	}
+}
+
+func TestNew_GlobalTracerProvider(t *testing.T) {
+	t.Parallel()
+	instrumentation := New(nil)
+	ctx := instrumentation.StartRequest(context.Background(), "GetUsers")
+	instrumentation.EndRequest(ctx, gocloak.RequestInfo{Operation: "GetUsers"})
+}
@@ -0,0 +1,244 @@
+package gocloak
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned if a request cannot be sent before the deadline of its context because of the rate limit
+var ErrRateLimited = errors.New("rate limit exceeded before the deadline of the context")
+
+// EndpointClass groups the keycloak endpoints which share a rate limit
+type EndpointClass string
+
+// The endpoint classes
+const (
+	// EndpointClassToken holds the public endpoints of the realms, e.g. the token and introspection endpoints
+	EndpointClassToken EndpointClass = "token"
+	// EndpointClassAdmin holds the admin REST API
+	EndpointClassAdmin EndpointClass = "admin"
+)
+
+// RateLimit limits the requests to keycloak.
+// Requests wait for the limits until their context is done
+type RateLimit struct {
+	// RequestsPerSecond is the rate of the token bucket, unlimited if zero
+	RequestsPerSecond float64
+	// Burst is the size of the token bucket, at least 1
+	Burst int
+	// MaxInFlight is the number of concurrent requests, unlimited if zero
+	MaxInFlight int
+}
+
+// SetRateLimit limits all requests to keycloak
+func SetRateLimit(limit RateLimit) ClientOption {
+	return func(client *gocloak) {
+		client.rateLimit = &limit
+	}
+}
+
+// SetEndpointRateLimit limits the requests to the endpoint class, in addition to the limit of all requests
+func SetEndpointRateLimit(class EndpointClass, limit RateLimit) ClientOption {
+	return func(client *gocloak) {
+		if client.endpointRateLimits == nil {
+			client.endpointRateLimits = make(map[EndpointClass]RateLimit)
+		}
+		client.endpointRateLimits[class] = limit
+	}
+}
+
+// setRateLimits wraps the transport of the HTTP client with the configured limits
+func (client *gocloak) setRateLimits() {
+	if client.rateLimit == nil && len(client.endpointRateLimits) == 0 {
+		return
+	}
+	transport := &limitedTransport{
+		next:        client.restyClient.GetClient().Transport,
+		adminPrefix: makeURL(client.adminBasePath, "admin") + urlSeparator,
+		classes:     make(map[EndpointClass]*limiter),
+	}
+	if transport.next == nil {
+		transport.next = http.DefaultTransport
+	}
+	if client.rateLimit != nil {
+		transport.all = newLimiter(*client.rateLimit)
+	}
+	for class, limit := range client.endpointRateLimits {
+		transport.classes[class] = newLimiter(limit)
+	}
+	client.restyClient.SetTransport(transport)
+}
+
+// limitedTransport waits for the limits before a request is sent
+// and frees its in-flight slot when the response body is closed
+type limitedTransport struct {
+	next        http.RoundTripper
+	adminPrefix string
+	all         *limiter
+	classes     map[EndpointClass]*limiter
+}
+
+func (t *limitedTransport) class(req *http.Request) EndpointClass {
+	if strings.HasPrefix(req.URL.String(), t.adminPrefix) {
+		return EndpointClassAdmin
+	}
+	return EndpointClassToken
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var acquired []*limiter
+	release := func() {
+		for _, l := range acquired {
+			l.release()
+		}
+	}
+	// the limit of the class is acquired first, so waiting for it does not block an in-flight slot of all requests
+	for _, l := range []*limiter{t.classes[t.class(req)], t.all} {
+		if l == nil {
+			continue
+		}
+		if err := l.acquire(req.Context()); err != nil {
+			release()
+			return nil, err
+		}
+		acquired = append(acquired, l)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		release()
+		return resp, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releasingBody calls release once when it is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+type limiter struct {
+	bucket   *tokenBucket
+	inFlight chan struct{}
+}
+
+func newLimiter(limit RateLimit) *limiter {
+	l := &limiter{}
+	if limit.RequestsPerSecond > 0 {
+		l.bucket = newTokenBucket(limit.RequestsPerSecond, limit.Burst)
+	}
+	if limit.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, limit.MaxInFlight)
+	}
+	return l
+}
+
+func (l *limiter) acquire(ctx context.Context) error {
+	if l.bucket != nil {
+		if err := l.bucket.wait(ctx); err != nil {
+			return err
+		}
+	}
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (l *limiter) release() {
+	if l.inFlight != nil {
+		<-l.inFlight
+	}
+}
+
+// tokenBucket refills rate tokens per second up to the burst size
+type tokenBucket struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	now := time.Now
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		now:    now,
+		tokens: float64(burst),
+		last:   now(),
+	}
+}
+
+// reserve takes a token and returns how long to wait until it is available
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a token which has been reserved but not used
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+}
+
+// wait takes a token, waiting until it is available. It fails at once
+// if the token is not available before the deadline of the context
+func (b *tokenBucket) wait(ctx context.Context) error {
+	wait := b.reserve()
+	if wait == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(b.now().Add(wait)) {
+		b.cancel()
+		return ErrRateLimited
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,140 @@
+package gocloak
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1000000, 0)
+	bucket := newTokenBucket(10, 2)
+	bucket.now = func() time.Time { return now }
+	bucket.last = now
+
+	assert.Zero(t, bucket.reserve())
+	assert.Zero(t, bucket.reserve())
+	assert.Equal(t, 100*time.Millisecond, bucket.reserve())
+	bucket.cancel()
+
+	now = now.Add(time.Second)
+	assert.Zero(t, bucket.reserve())
+	assert.Zero(t, bucket.reserve())
+	assert.Equal(t, 100*time.Millisecond, bucket.reserve())
+}
+
+func TestTokenBucket_Wait(t *testing.T) {
+	t.Parallel()
+	bucket := newTokenBucket(20, 1)
+	assert.NoError(t, bucket.wait(context.Background()))
+
+	// the deadline is too close to wait for the next token
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.Equal(t, ErrRateLimited, bucket.wait(ctx))
+	assert.True(t, time.Since(start) < 10*time.Millisecond)
+
+	assert.NoError(t, bucket.wait(context.Background()))
+	assert.True(t, time.Since(start) >= 40*time.Millisecond)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, bucket.wait(ctx))
+}
+
+func TestLimitedTransport_Class(t *testing.T) {
+	t.Parallel()
+	client := NewClient("http://keycloak", SetRateLimit(RateLimit{MaxInFlight: 1})).(*gocloak)
+	transport := client.restyClient.GetClient().Transport.(*limitedTransport)
+	for rawURL, class := range map[string]EndpointClass{
+		"http://keycloak/auth/admin/realms/realm/users":                      EndpointClassAdmin,
+		"http://keycloak/auth/admin/serverinfo":                              EndpointClassAdmin,
+		"http://keycloak/auth/realms/realm/protocol/openid-connect/token":    EndpointClassToken,
+		"http://keycloak/auth/realms/admin/protocol/openid-connect/userinfo": EndpointClassToken,
+	} {
+		u, err := url.Parse(rawURL)
+		assert.NoError(t, err)
+		assert.Equal(t, class, transport.class(&http.Request{URL: u}), rawURL)
+	}
+}
+
+func TestGocloak_RateLimit_MaxInFlight(t *testing.T) {
+	t.Parallel()
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL,
+		SetRateLimit(RateLimit{MaxInFlight: 3}),
+		SetEndpointRateLimit(EndpointClassAdmin, RateLimit{MaxInFlight: 1}),
+	)
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetRealm(ctx, "token", "realm")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, atomic.LoadInt32(&maxInFlight))
+
+	atomic.StoreInt32(&maxInFlight, 0)
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetIssuer(ctx, "realm")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.True(t, atomic.LoadInt32(&maxInFlight) > 1)
+	assert.True(t, atomic.LoadInt32(&maxInFlight) <= 3)
+}
+
+func TestGocloak_RateLimit_Context(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, SetEndpointRateLimit(EndpointClassToken, RateLimit{RequestsPerSecond: 1}))
+	_, err := client.GetIssuer(context.Background(), "realm")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = client.GetIssuer(ctx, "realm")
+	assert.True(t, errors.Is(err, ErrRateLimited))
+
+	// the admin API is not limited
+	_, err = client.GetRealm(ctx, "token", "realm")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,201 @@
+package gocloak
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// defaultRetryStatusCodes are the status codes of transient failures, e.g. while keycloak restarts
+var defaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryInfo describes a failed attempt which is retried
+type RetryInfo struct {
+	// Attempt is the number of the failed attempt, starting with 1
+	Attempt int
+	Method  string
+	URL     string
+	// StatusCode is the status code of the response, zero if the request failed without a response
+	StatusCode int
+	// Err is the error of a request which failed without a response
+	Err error
+	// Wait is the time until the retry
+	Wait time.Duration
+}
+
+// DefaultRetryPolicy returns a policy which retries idempotent requests three times
+// with a wait time from 100ms to 5s
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:  3,
+		WaitTime:    100 * time.Millisecond,
+		MaxWaitTime: 5 * time.Second,
+		Jitter:      0.5,
+	}
+}
+
+type retryStateKey struct{}
+
+// retryState counts the attempts of a request
+type retryState struct {
+	attempts int
+	wait     time.Duration
+	// authenticate adds new client credentials to a retried request, so a signed client assertion is not replayed
+	authenticate func(req *resty.Request) error
+}
+
+// withRetryState adds the attempt counter of a new request to the context
+func withRetryState(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryStateKey{}, &retryState{})
+}
+
+func getRetryState(ctx context.Context) *retryState {
+	if ctx == nil {
+		return nil
+	}
+	state, _ := ctx.Value(retryStateKey{}).(*retryState)
+	return state
+}
+
+// setRetryPolicy configures the retries of the resty client.
+// resty counts the first attempt as a retry and waits after the last attempt, so the policy decides
+// in the retry condition whether another attempt is made and computes the wait time itself
+func (client *gocloak) setRetryPolicy(policy *RetryPolicy) {
+	if policy.MaxRetries <= 0 {
+		return
+	}
+	if len(policy.RetryStatusCodes) == 0 {
+		policy.RetryStatusCodes = defaultRetryStatusCodes
+	}
+	client.restyClient.
+		SetRetryCount(policy.MaxRetries + 1).
+		SetRetryWaitTime(0).
+		SetRetryMaxWaitTime(math.MaxInt64).
+		AddRetryCondition(policy.retry).
+		SetRetryAfter(func(_ *resty.Client, resp *resty.Response) (time.Duration, error) {
+			if state := getRetryState(resp.Request.Context()); state != nil && state.wait > 0 {
+				return state.wait, nil
+			}
+			return time.Nanosecond, nil
+		}).
+		OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+			if state := getRetryState(req.Context()); state != nil && state.attempts > 0 && state.authenticate != nil {
+				if err := state.authenticate(req); err != nil {
+					// resty returns no response for a failed middleware, so the instrumented request is ended here
+					endRequest(req, nil, err)
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+// retry decides whether the attempt is retried and computes the wait time
+func (policy *RetryPolicy) retry(resp *resty.Response, err error) bool {
+	if resp == nil || resp.Request == nil {
+		return false
+	}
+	state := getRetryState(resp.Request.Context())
+	if state == nil || state.attempts >= policy.MaxRetries {
+		return false
+	}
+	if !policy.retryMethod(resp.Request.Method) {
+		return false
+	}
+	if resp.RawResponse == nil {
+		// the request failed without a response, e.g. because the connection was reset
+		if err == nil {
+			return false
+		}
+	} else if !policy.retryStatus(resp.StatusCode()) {
+		return false
+	}
+
+	state.attempts++
+	state.wait = policy.waitTime(state.attempts, resp)
+	if policy.OnRetry != nil {
+		info := RetryInfo{
+			Attempt: state.attempts,
+			Method:  resp.Request.Method,
+			URL:     resp.Request.URL,
+			Err:     err,
+			Wait:    state.wait,
+		}
+		if resp.RawResponse != nil {
+			info.StatusCode = resp.StatusCode()
+		}
+		policy.OnRetry(info)
+	}
+	return true
+}
+
+func (policy *RetryPolicy) retryMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return policy.RetryPOST
+	}
+	return false
+}
+
+func (policy *RetryPolicy) retryStatus(statusCode int) bool {
+	for _, code := range policy.RetryStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// waitTime returns the wait time of the Retry-After header, or the exponential backoff with jitter.
+// Both are limited to the max wait time
+func (policy *RetryPolicy) waitTime(attempt int, resp *resty.Response) time.Duration {
+	if wait, ok := retryAfter(resp, time.Now()); ok {
+		return policy.limit(wait)
+	}
+
+	wait := policy.limit(time.Duration(float64(policy.WaitTime) * math.Pow(2, float64(attempt-1))))
+	if policy.Jitter > 0 {
+		wait -= time.Duration(rand.Float64() * math.Min(policy.Jitter, 1) * float64(wait))
+	}
+	return wait
+}
+
+func (policy *RetryPolicy) limit(wait time.Duration) time.Duration {
+	if policy.MaxWaitTime > 0 && (wait > policy.MaxWaitTime || wait < 0) {
+		return policy.MaxWaitTime
+	}
+	return wait
+}
+
+// retryAfter parses the Retry-After header, which holds either seconds or a date
+func retryAfter(resp *resty.Response, now time.Time) (time.Duration, bool) {
+	if resp.RawResponse == nil {
+		return 0, false
+	}
+	value := resp.Header().Get("Retry-After")
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,187 @@
+package gocloak
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyServer fails the first attempts of each request with the handler and answers the later ones
+func flakyServer(t *testing.T, failures int32, fail func(w http.ResponseWriter)) (*httptest.Server, *int32) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= failures {
+			fail(w)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"realm":"realm"}`))
+	}))
+	return server, &attempts
+}
+
+func closeConnection(t *testing.T) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		assert.NoError(t, err)
+		_ = conn.Close()
+	}
+}
+
+func TestRetryPolicy_ConnectionErrors(t *testing.T) {
+	t.Parallel()
+	server, attempts := flakyServer(t, 2, closeConnection(t))
+	defer server.Close()
+	var mu sync.Mutex
+	var retries []RetryInfo
+
+	client := NewClient(server.URL, SetRetryPolicy(RetryPolicy{
+		MaxRetries:  2,
+		WaitTime:    time.Millisecond,
+		MaxWaitTime: 10 * time.Millisecond,
+		Jitter:      0.5,
+		OnRetry: func(info RetryInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			retries = append(retries, info)
+		},
+	}))
+	issuer, err := client.GetIssuer(context.Background(), "realm")
+	assert.NoError(t, err)
+	assert.Equal(t, "realm", PString(issuer.Realm))
+	assert.EqualValues(t, 3, atomic.LoadInt32(attempts))
+
+	assert.Len(t, retries, 2)
+	assert.Equal(t, 1, retries[0].Attempt)
+	assert.Equal(t, 2, retries[1].Attempt)
+	assert.Equal(t, http.MethodGet, retries[0].Method)
+	assert.Equal(t, server.URL+"/auth/realms/realm", retries[0].URL)
+	assert.Error(t, retries[0].Err)
+	assert.Zero(t, retries[0].StatusCode)
+	assert.True(t, retries[1].Wait <= 2*time.Millisecond)
+}
+
+func TestRetryPolicy_StatusCodes(t *testing.T) {
+	t.Parallel()
+	server, attempts := flakyServer(t, 10, func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+	var waits []time.Duration
+
+	client := NewClient(server.URL, SetRetryPolicy(RetryPolicy{
+		MaxRetries: 3,
+		WaitTime:   time.Hour,
+		OnRetry: func(info RetryInfo) {
+			assert.Equal(t, http.StatusServiceUnavailable, info.StatusCode)
+			waits = append(waits, info.Wait)
+		},
+	}))
+	_, err := client.GetIssuer(context.Background(), "realm")
+	assert.Equal(t, http.StatusServiceUnavailable, err.(*APIError).Code)
+	assert.EqualValues(t, 4, atomic.LoadInt32(attempts))
+	// Retry-After overrides the backoff
+	assert.Equal(t, []time.Duration{0, 0, 0}, waits)
+}
+
+func TestRetryPolicy_POST(t *testing.T) {
+	t.Parallel()
+	server, attempts := flakyServer(t, 1, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	defer server.Close()
+	policy := RetryPolicy{MaxRetries: 1, WaitTime: time.Millisecond}
+
+	client := NewClient(server.URL, SetRetryPolicy(policy))
+	_, err := client.LoginClient(context.Background(), "my-client", "secret", "realm")
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(attempts))
+
+	policy.RetryPOST = true
+	client = NewClient(server.URL, SetRetryPolicy(policy))
+	atomic.StoreInt32(attempts, 0)
+	_, err = client.LoginClient(context.Background(), "my-client", "secret", "realm")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(attempts))
+}
+
+func TestRetryPolicy_POSTNewClientAssertion(t *testing.T) {
+	t.Parallel()
+	var attempts int32
+	assertions := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assertions <- r.PostForm.Get("client_assertion")
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"access"}`))
+	}))
+	defer server.Close()
+	authenticator, err := NewClientSecretJWTAuthenticator([]byte("secret"))
+	assert.NoError(t, err)
+
+	client := NewClient(server.URL, SetRetryPolicy(RetryPolicy{MaxRetries: 1, WaitTime: time.Millisecond, RetryPOST: true}))
+	_, err = client.GetToken(context.Background(), "realm", TokenOptions{
+		ClientID:            StringP("my-client"),
+		GrantType:           StringP("client_credentials"),
+		ClientAuthenticator: authenticator,
+	})
+	assert.NoError(t, err)
+	first, second := <-assertions, <-assertions
+	assert.NotEmpty(t, first)
+	assert.NotEmpty(t, second)
+	assert.NotEqual(t, first, second, "the client assertion of the first attempt was replayed")
+}
+
+func TestRetryPolicy_Context(t *testing.T) {
+	t.Parallel()
+	server, attempts := flakyServer(t, 10, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := NewClient(server.URL, SetRetryPolicy(RetryPolicy{MaxRetries: 3, WaitTime: time.Hour}))
+	start := time.Now()
+	_, err := client.GetIssuer(ctx, "realm")
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+	assert.EqualValues(t, 1, atomic.LoadInt32(attempts))
+}
+
+func TestRetryPolicy_WaitTime(t *testing.T) {
+	t.Parallel()
+	policy := RetryPolicy{WaitTime: 100 * time.Millisecond, MaxWaitTime: time.Second, Jitter: 0.5}
+	resp := &resty.Response{RawResponse: &http.Response{Header: http.Header{}}}
+	for attempt, max := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		wait := policy.waitTime(attempt+1, resp)
+		assert.True(t, wait <= max*time.Millisecond, "attempt %d waits %s", attempt+1, wait)
+		assert.True(t, wait >= max*time.Millisecond/2, "attempt %d waits %s", attempt+1, wait)
+	}
+
+	resp.RawResponse.Header.Set("Retry-After", "3")
+	assert.Equal(t, time.Second, policy.waitTime(1, resp))
+	policy.MaxWaitTime = 0
+	assert.Equal(t, 3*time.Second, policy.waitTime(1, resp))
+
+	now := time.Now()
+	resp.RawResponse.Header.Set("Retry-After", now.Add(2*time.Minute).UTC().Format(http.TimeFormat))
+	wait, ok := retryAfter(resp, now)
+	assert.True(t, ok)
+	assert.InDelta(t, float64(2*time.Minute), float64(wait), float64(time.Second))
+	resp.RawResponse.Header.Set("Retry-After", "soon")
+	_, ok = retryAfter(resp, now)
+	assert.False(t, ok)
+}
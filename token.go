@@ -11,4 +11,20 @@ type JWT struct {
 	NotBeforePolicy  int    `json:"not-before-policy"`
 	SessionState     string `json:"session_state"`
 	Scope            string `json:"scope"`
+	IssuedTokenType  string `json:"issued_token_type,omitempty"`
 }
+
+// Token types of the token exchange (RFC 8693)
+const (
+	TokenTypeAccessToken  = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeRefreshToken = "urn:ietf:params:oauth:token-type:refresh_token"
+	TokenTypeIDToken      = "urn:ietf:params:oauth:token-type:id_token"
+	TokenTypeJWT          = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// Token type hints of the introspection (RFC 7662) and revocation (RFC 7009) endpoints
+const (
+	TokenTypeHintAccessToken          = "access_token"
+	TokenTypeHintRefreshToken         = "refresh_token"
+	TokenTypeHintRequestingPartyToken = "requesting_party_token"
+)
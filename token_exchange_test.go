@@ -0,0 +1,61 @@
+package gocloak
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGocloak_ExchangeToken(t *testing.T) {
+	t.Parallel()
+	forms := make(chan url.Values, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/auth/realms/realm/protocol/openid-connect/token", r.URL.Path)
+		assert.NoError(t, r.ParseForm())
+		forms <- r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(JWT{AccessToken: "exchanged"})
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	token, err := client.ExchangeToken(context.Background(), "realm", TokenOptions{
+		ClientID:     StringP("gateway"),
+		ClientSecret: StringP("secret"),
+		SubjectToken: StringP("subject"),
+		Audience:     StringP("downstream"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "exchanged", token.AccessToken)
+	form := <-forms
+	assert.Equal(t, "urn:ietf:params:oauth:grant-type:token-exchange", form.Get("grant_type"))
+	assert.Equal(t, "subject", form.Get("subject_token"))
+	assert.Equal(t, TokenTypeAccessToken, form.Get("subject_token_type"))
+	assert.Equal(t, "downstream", form.Get("audience"))
+	assert.NotContains(t, form, "response_type")
+
+	// the subject token type is kept, and not set without a subject token, e.g. for the impersonation of a user
+	_, err = client.ExchangeToken(context.Background(), "realm", TokenOptions{
+		ClientID:         StringP("gateway"),
+		ClientSecret:     StringP("secret"),
+		SubjectToken:     StringP("subject"),
+		SubjectTokenType: StringP(TokenTypeIDToken),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, TokenTypeIDToken, (<-forms).Get("subject_token_type"))
+
+	_, err = client.ExchangeToken(context.Background(), "realm", TokenOptions{
+		ClientID:         StringP("gateway"),
+		ClientSecret:     StringP("secret"),
+		RequestedSubject: StringP("john"),
+	})
+	assert.NoError(t, err)
+	form = <-forms
+	assert.Equal(t, "john", form.Get("requested_subject"))
+	assert.NotContains(t, form, "subject_token_type")
+}
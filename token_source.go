@@ -0,0 +1,222 @@
+package gocloak
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// tokenRefreshRatio is the part of the token lifetime after which the token is refreshed
+	tokenRefreshRatio = 0.8
+	// tokenRefreshRetry is the delay before a failed background refresh is retried
+	tokenRefreshRetry = 5 * time.Second
+	// tokenExpiryMargin is how long before its expiry a token is no longer handed out,
+	// so it does not expire on the way to keycloak. It is at most a tenth of the lifetime
+	tokenExpiryMargin = 10 * time.Second
+	// tokenDefaultLifetime is the lifetime of tokens without expires_in
+	tokenDefaultLifetime = time.Minute
+	// tokenFetchTimeout limits a request for a new token, which is shared by all callers
+	tokenFetchTimeout = 30 * time.Second
+)
+
+// TokenSource hands out valid tokens and refreshes them ahead of their expiry
+type TokenSource interface {
+	// Token returns a valid token, refreshing it or logging in again if needed
+	Token(ctx context.Context) (*JWT, error)
+	// AccessToken returns a valid access token to be used in the admin calls
+	AccessToken(ctx context.Context) (string, error)
+	// Close stops the background refresh
+	Close()
+}
+
+type tokenCall struct {
+	done  chan struct{}
+	token *JWT
+	err   error
+}
+
+type tokenSource struct {
+	client  GoCloak
+	realm   string
+	options TokenOptions
+
+	mu               sync.Mutex
+	token            *JWT
+	expiresAt        time.Time
+	refreshExpiresAt time.Time
+	call             *tokenCall
+	timer            *time.Timer
+	closed           bool
+	ctx              context.Context
+	cancel           context.CancelFunc
+	now              func() time.Time
+}
+
+// NewTokenSource creates a token source which obtains tokens from the token endpoint of the given realm
+// using the given options and refreshes them in the background ahead of their expiry
+func NewTokenSource(client GoCloak, realm string, options TokenOptions) TokenSource {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &tokenSource{
+		client:  client,
+		realm:   realm,
+		options: options,
+		ctx:     ctx,
+		cancel:  cancel,
+		now:     time.Now,
+	}
+}
+
+// NewClientTokenSource creates a token source for a service account using client credentials
+func NewClientTokenSource(client GoCloak, clientID, clientSecret, realm string) TokenSource {
+	return NewTokenSource(client, realm, TokenOptions{
+		ClientID:     &clientID,
+		ClientSecret: &clientSecret,
+		GrantType:    StringP("client_credentials"),
+	})
+}
+
+// NewAdminTokenSource creates a token source for an admin user using the admin client
+func NewAdminTokenSource(client GoCloak, username, password, realm string) TokenSource {
+	return NewTokenSource(client, realm, TokenOptions{
+		ClientID:  StringP(adminClientID),
+		GrantType: StringP("password"),
+		Username:  &username,
+		Password:  &password,
+	})
+}
+
+// Token returns a valid token. Concurrent callers share a single request to keycloak
+func (ts *tokenSource) Token(ctx context.Context) (*JWT, error) {
+	ts.mu.Lock()
+	if ts.closed {
+		ts.mu.Unlock()
+		return nil, errors.New("token source is closed")
+	}
+	if ts.token != nil && ts.now().Before(ts.expiresAt) {
+		token := ts.token
+		ts.mu.Unlock()
+		return token, nil
+	}
+	call := ts.startRefresh()
+	ts.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// AccessToken returns a valid access token
+func (ts *tokenSource) AccessToken(ctx context.Context) (string, error) {
+	token, err := ts.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// Close stops the background refresh and cancels a request in flight
+func (ts *tokenSource) Close() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.closed = true
+	ts.cancel()
+	if ts.timer != nil {
+		ts.timer.Stop()
+		ts.timer = nil
+	}
+}
+
+// startRefresh starts a new refresh or returns the one in flight, ts.mu must be held
+func (ts *tokenSource) startRefresh() *tokenCall {
+	if ts.call != nil {
+		return ts.call
+	}
+	call := &tokenCall{done: make(chan struct{})}
+	ts.call = call
+	refreshToken := ""
+	if ts.token != nil && (ts.refreshExpiresAt.IsZero() || ts.now().Before(ts.refreshExpiresAt)) {
+		refreshToken = ts.token.RefreshToken
+	}
+
+	go func() {
+		// the request is not bound to the context of a single caller, because all callers share it
+		ctx, cancel := context.WithTimeout(ts.ctx, tokenFetchTimeout)
+		token, err := ts.fetch(ctx, refreshToken)
+		cancel()
+
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		call.token, call.err = token, err
+		ts.call = nil
+		if err == nil {
+			ts.store(token)
+		} else if ts.token != nil {
+			ts.schedule(tokenRefreshRetry)
+		}
+		close(call.done)
+	}()
+
+	return call
+}
+
+func (ts *tokenSource) fetch(ctx context.Context, refreshToken string) (*JWT, error) {
+	if len(refreshToken) > 0 {
+		// the client authenticates the refresh the same way as the login
+		token, err := ts.client.GetToken(ctx, ts.realm, TokenOptions{
+			ClientID:            ts.options.ClientID,
+			ClientSecret:        ts.options.ClientSecret,
+			ClientAuthenticator: ts.options.ClientAuthenticator,
+			GrantType:           StringP("refresh_token"),
+			RefreshToken:        &refreshToken,
+			Scopes:              ts.options.Scopes,
+			Scope:               ts.options.Scope,
+		})
+		if err == nil {
+			return token, nil
+		}
+		// the session might be gone, fall back to a new login
+	}
+	return ts.client.GetToken(ctx, ts.realm, ts.options)
+}
+
+// store saves the token and schedules the next refresh, ts.mu must be held
+func (ts *tokenSource) store(token *JWT) {
+	now := ts.now()
+	lifetime := time.Duration(token.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = tokenDefaultLifetime
+	}
+	margin := lifetime / 10
+	if margin > tokenExpiryMargin {
+		margin = tokenExpiryMargin
+	}
+	ts.token = token
+	ts.expiresAt = now.Add(lifetime - margin)
+	ts.refreshExpiresAt = time.Time{}
+	if token.RefreshExpiresIn > 0 {
+		ts.refreshExpiresAt = now.Add(time.Duration(token.RefreshExpiresIn) * time.Second)
+	}
+	ts.schedule(time.Duration(float64(lifetime) * tokenRefreshRatio))
+}
+
+// schedule starts the background refresh after the given delay, ts.mu must be held
+func (ts *tokenSource) schedule(delay time.Duration) {
+	if ts.closed {
+		return
+	}
+	if ts.timer != nil {
+		ts.timer.Stop()
+	}
+	ts.timer = time.AfterFunc(delay, func() {
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		if !ts.closed {
+			ts.startRefresh()
+		}
+	})
+}
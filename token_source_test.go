@@ -0,0 +1,200 @@
+package gocloak
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tokenSourceClient struct {
+	GoCloak
+	logins      int32
+	refreshes   int32
+	failRefresh bool
+	expiresIn   int
+	delay       time.Duration
+
+	mu             sync.Mutex
+	refreshOptions TokenOptions
+}
+
+func (c *tokenSourceClient) GetToken(ctx context.Context, realm string, options TokenOptions) (*JWT, error) {
+	if PString(options.GrantType) == "refresh_token" {
+		return c.refresh(options)
+	}
+	n := atomic.AddInt32(&c.logins, 1)
+	select {
+	case <-time.After(c.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &JWT{
+		AccessToken:      "login" + strconv.Itoa(int(n)),
+		RefreshToken:     "refresh",
+		ExpiresIn:        c.expiresIn,
+		RefreshExpiresIn: 60,
+	}, nil
+}
+
+func (c *tokenSourceClient) refresh(options TokenOptions) (*JWT, error) {
+	n := atomic.AddInt32(&c.refreshes, 1)
+	c.mu.Lock()
+	c.refreshOptions = options
+	c.mu.Unlock()
+	if c.failRefresh {
+		return nil, errors.New("session not active")
+	}
+	return &JWT{
+		AccessToken:      "refresh" + strconv.Itoa(int(n)),
+		RefreshToken:     "refresh",
+		ExpiresIn:        c.expiresIn,
+		RefreshExpiresIn: 60,
+	}, nil
+}
+
+func TestTokenSource_DeduplicatesConcurrentRequests(t *testing.T) {
+	t.Parallel()
+	client := &tokenSourceClient{expiresIn: 60, delay: 50 * time.Millisecond}
+	ts := NewClientTokenSource(client, "client", "secret", "realm")
+	defer ts.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := ts.AccessToken(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "login1", token)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, atomic.LoadInt32(&client.logins))
+}
+
+func TestTokenSource_RefreshesInBackground(t *testing.T) {
+	t.Parallel()
+	client := &tokenSourceClient{expiresIn: 1}
+	ts := NewClientTokenSource(client, "client", "secret", "realm")
+	defer ts.Close()
+
+	token, err := ts.AccessToken(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "login1", token)
+
+	time.Sleep(1500 * time.Millisecond)
+	assert.True(t, atomic.LoadInt32(&client.refreshes) > 0, "token has not been refreshed")
+	token, err = ts.AccessToken(context.Background())
+	assert.NoError(t, err)
+	assert.Contains(t, token, "refresh")
+	assert.EqualValues(t, 1, atomic.LoadInt32(&client.logins))
+}
+
+func TestTokenSource_RefreshUsesClientAuthenticator(t *testing.T) {
+	t.Parallel()
+	client := &tokenSourceClient{expiresIn: 1}
+	authenticator := NewClientSecretBasicAuthenticator("secret")
+	ts := NewTokenSource(client, "realm", TokenOptions{
+		ClientID:            StringP("client"),
+		ClientAuthenticator: authenticator,
+		GrantType:           StringP("client_credentials"),
+		Scope:               StringP("openid"),
+	})
+	defer ts.Close()
+
+	_, err := ts.Token(context.Background())
+	assert.NoError(t, err)
+	time.Sleep(1500 * time.Millisecond)
+	assert.True(t, atomic.LoadInt32(&client.refreshes) > 0, "token has not been refreshed")
+
+	client.mu.Lock()
+	options := client.refreshOptions
+	client.mu.Unlock()
+	assert.Equal(t, "client", PString(options.ClientID))
+	assert.Equal(t, authenticator, options.ClientAuthenticator)
+	assert.Equal(t, "refresh", PString(options.RefreshToken))
+	assert.Equal(t, "openid", PString(options.Scope))
+}
+
+func TestTokenSource_FallsBackToLogin(t *testing.T) {
+	t.Parallel()
+	client := &tokenSourceClient{expiresIn: 1, failRefresh: true}
+	ts := NewClientTokenSource(client, "client", "secret", "realm")
+	defer ts.Close()
+
+	_, err := ts.Token(context.Background())
+	assert.NoError(t, err)
+	time.Sleep(1500 * time.Millisecond)
+	token, err := ts.AccessToken(context.Background())
+	assert.NoError(t, err)
+	assert.Contains(t, token, "login")
+	assert.True(t, atomic.LoadInt32(&client.logins) > 1, "no fresh login after a failed refresh")
+}
+
+func TestTokenSource_CanceledContext(t *testing.T) {
+	t.Parallel()
+	client := &tokenSourceClient{expiresIn: 60, delay: time.Second}
+	ts := NewClientTokenSource(client, "client", "secret", "realm")
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := ts.Token(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestTokenSource_Closed(t *testing.T) {
+	t.Parallel()
+	client := &tokenSourceClient{expiresIn: 60}
+	ts := NewClientTokenSource(client, "client", "secret", "realm")
+	ts.Close()
+	_, err := ts.Token(context.Background())
+	assert.Error(t, err)
+}
+
+func TestTokenSource_CloseCancelsFetch(t *testing.T) {
+	t.Parallel()
+	client := &tokenSourceClient{expiresIn: 60, delay: time.Minute}
+	ts := NewClientTokenSource(client, "client", "secret", "realm")
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := ts.Token(context.Background())
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	ts.Close()
+	select {
+	case err := <-errs:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("the fetch has not been canceled")
+	}
+}
+
+func TestTokenSource_ExpiryMargin(t *testing.T) {
+	t.Parallel()
+	for expiresIn, validFor := range map[int]time.Duration{
+		// a tenth of short lifetimes
+		10: 9 * time.Second,
+		// at most ten seconds
+		300: 290 * time.Second,
+		// tokens without expires_in are kept for a minute
+		0: 54 * time.Second,
+	} {
+		now := time.Unix(1000000, 0)
+		ts := NewClientTokenSource(&tokenSourceClient{}, "client", "secret", "realm").(*tokenSource)
+		ts.now = func() time.Time { return now }
+		ts.mu.Lock()
+		ts.store(&JWT{AccessToken: "token", ExpiresIn: expiresIn})
+		ts.mu.Unlock()
+		ts.Close()
+		assert.Equal(t, now.Add(validFor), ts.expiresAt, expiresIn)
+	}
+}
@@ -0,0 +1,71 @@
+package gocloak
+
+import "context"
+
+// CreatePermissionTicket creates a permission ticket for the resources and scopes a client asked for.
+// The token must be a protection API token of the resource server
+func (client *gocloak) CreatePermissionTicket(ctx context.Context, token, realm string, permissions []CreatePermissionTicketParams) (*PermissionTicketResponseRepresentation, error) {
+	var result PermissionTicketResponseRepresentation
+	resp, err := client.getRequestWithBearerAuth(ctx, "CreatePermissionTicket", realm, token).
+		SetBody(permissions).
+		SetResult(&result).
+		Post(client.getRealmURL(realm, "authz", "protection", "permission"))
+
+	if err := checkForError(resp, err); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetRequestingPartyToken obtains a requesting party token (RPT) with the uma-ticket grant,
+// for a permission ticket or for the permissions of an audience. An RPT is upgraded by setting it in the options
+func (client *gocloak) GetRequestingPartyToken(ctx context.Context, token, realm string, options RequestingPartyTokenOptions) (*JWT, error) {
+	var result JWT
+	if err := client.requestingPartyToken(ctx, "GetRequestingPartyToken", token, realm, options, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetRequestingPartyPermissions returns the permissions which would be granted, without issuing an RPT
+func (client *gocloak) GetRequestingPartyPermissions(ctx context.Context, token, realm string, options RequestingPartyTokenOptions) (*[]RequestingPartyPermission, error) {
+	options.ResponseMode = StringP("permissions")
+	var result []RequestingPartyPermission
+	if err := client.requestingPartyToken(ctx, "GetRequestingPartyPermissions", token, realm, options, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetRequestingPartyPermissionDecision returns whether the permissions would be granted, without issuing an RPT
+func (client *gocloak) GetRequestingPartyPermissionDecision(ctx context.Context, token, realm string, options RequestingPartyTokenOptions) (*RequestingPartyPermissionDecision, error) {
+	options.ResponseMode = StringP("decision")
+	var result RequestingPartyPermissionDecision
+	if err := client.requestingPartyToken(ctx, "GetRequestingPartyPermissionDecision", token, realm, options, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (client *gocloak) requestingPartyToken(ctx context.Context, operation, token, realm string, options RequestingPartyTokenOptions, result interface{}) error {
+	formData, err := options.FormData()
+	if err != nil {
+		return err
+	}
+	endpointURL, err := client.getEndpointURL(ctx, realm, tokenURL)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.getRequest(ctx, operation, realm).
+		SetAuthToken(token).
+		SetFormDataFromValues(formData).
+		SetResult(result).
+		Post(endpointURL)
+
+	return checkForError(resp, err)
+}
@@ -0,0 +1,87 @@
+package gocloak
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func umaServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/auth/realms/realm/authz/protection/permission":
+			var permissions []CreatePermissionTicketParams
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&permissions))
+			assert.Equal(t, "7f3a", PString(permissions[0].ResourceID))
+			_, _ = w.Write([]byte(`{"ticket":"ticket"}`))
+		case "/auth/realms/realm/protocol/openid-connect/token":
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:uma-ticket", r.PostForm.Get("grant_type"))
+			switch r.PostForm.Get("response_mode") {
+			case "decision":
+				_, _ = w.Write([]byte(`{"result":true}`))
+			case "permissions":
+				assert.Equal(t, []string{"Orders#view", "Invoices"}, r.PostForm["permission"])
+				assert.Equal(t, "my-resource-server", r.PostForm.Get("audience"))
+				_, _ = w.Write([]byte(`[{"rsid":"7f3a","rsname":"Orders","scopes":["view"]}]`))
+			default:
+				assert.Equal(t, "ticket", r.PostForm.Get("ticket"))
+				assert.Equal(t, "old-rpt", r.PostForm.Get("rpt"))
+				assert.Equal(t, "true", r.PostForm.Get("submit_request"))
+				_, _ = w.Write([]byte(`{"access_token":"rpt","token_type":"Bearer"}`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGocloak_CreatePermissionTicket(t *testing.T) {
+	t.Parallel()
+	server := umaServer(t)
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	ticket, err := client.CreatePermissionTicket(context.Background(), "token", "realm", []CreatePermissionTicketParams{
+		{
+			ResourceID:     StringP("7f3a"),
+			ResourceScopes: &[]string{"view"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ticket", PString(ticket.Ticket))
+}
+
+func TestGocloak_GetRequestingPartyToken(t *testing.T) {
+	t.Parallel()
+	server := umaServer(t)
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	rpt, err := client.GetRequestingPartyToken(context.Background(), "token", "realm", RequestingPartyTokenOptions{
+		Ticket:        StringP("ticket"),
+		RPT:           StringP("old-rpt"),
+		SubmitRequest: BoolP(true),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "rpt", rpt.AccessToken)
+
+	options := RequestingPartyTokenOptions{
+		Audience:    StringP("my-resource-server"),
+		Permissions: &[]string{"Orders#view", "Invoices"},
+	}
+	permissions, err := client.GetRequestingPartyPermissions(context.Background(), "token", "realm", options)
+	assert.NoError(t, err)
+	assert.Len(t, *permissions, 1)
+	assert.Equal(t, "Orders", PString((*permissions)[0].ResourceName))
+
+	decision, err := client.GetRequestingPartyPermissionDecision(context.Background(), "token", "realm", options)
+	assert.NoError(t, err)
+	assert.True(t, PBool(decision.Result))
+}